@@ -0,0 +1,339 @@
+package mig
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+const BASELINE_FILE_NAME = "0001_baseline.sql"
+
+// Baseline introspects the live database schema and returns a migration with
+// id 1 that recreates it. The up section holds the CREATE statements and the
+// down section drops everything again in reverse dependency order.
+// The tables mig keeps its own records in are never part of the baseline.
+func (mig *Mig) Baseline() (Migration, error) {
+	var (
+		up, down []string
+		err      error
+	)
+
+	switch mig.dialect {
+	case dialectSqlite:
+		up, down, err = mig.sqliteSchema()
+	case dialectPostgres:
+		up, down, err = mig.postgresSchema()
+	default:
+		return Migration{}, fmt.Errorf("mig: baseline is not supported for %s databases", mig.dialect)
+	}
+	if err != nil {
+		return Migration{}, fmt.Errorf("mig: error reading database schema: %w", err)
+	}
+
+	return Migration{
+		Id:       1,
+		FileName: BASELINE_FILE_NAME,
+		Up:       strings.Join(up, "\n\n"),
+		Down:     strings.Join(down, "\n"),
+	}, nil
+}
+
+// WriteBaseline writes the baseline migration to dir as 0001_baseline.sql,
+// using the configured delimiters. It refuses to overwrite an existing file.
+func (mig *Mig) WriteBaseline(dir string) error {
+	m, err := mig.Baseline()
+	if err != nil {
+		return err
+	}
+
+	f, err := os.OpenFile(filepath.Join(dir, m.FileName), os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o644)
+	if err != nil {
+		return fmt.Errorf("mig: error creating baseline file: %w", err)
+	}
+	defer f.Close()
+
+	_, err = f.WriteString(getRaw(m.Up, m.Down, mig.config.UpDelimiter, mig.config.DownDelimiter) + "\n")
+	if err != nil {
+		return fmt.Errorf("mig: error writing baseline file: %w", err)
+	}
+
+	return f.Close()
+}
+
+func (mig *Mig) sqliteSchema() (up []string, down []string, err error) {
+	type object struct {
+		kind, name, sql string
+	}
+
+	rows, err := mig.config.Db.Query(`
+		SELECT type, name, sql
+		FROM sqlite_master
+		WHERE sql IS NOT NULL AND name NOT LIKE 'sqlite_%' AND tbl_name NOT IN ($1, $2, $3, $4, $5)
+		ORDER BY rowid`,
+		mig.tableName(),
+		mig.tableName()+repeatableTableSuffix,
+		mig.tableName()+layoutTableSuffix,
+		mig.repairName(),
+		mig.historyName(),
+	)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var (
+		tables []string
+		others []object
+		create = map[string]string{}
+	)
+	for rows.Next() {
+		var o object
+		err = rows.Scan(&o.kind, &o.name, &o.sql)
+		if err != nil {
+			rows.Close()
+			return nil, nil, err
+		}
+
+		if o.kind == "table" {
+			tables = append(tables, o.name)
+			create[o.name] = o.sql
+		} else {
+			others = append(others, o)
+		}
+	}
+	rows.Close()
+	if err = rows.Err(); err != nil {
+		return nil, nil, err
+	}
+
+	deps := map[string][]string{}
+	for _, table := range tables {
+		deps[table], err = mig.queryStrings(`SELECT "table" FROM pragma_foreign_key_list($1)`, table)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+
+	// a cycle is not fatal here, sqlite does not check references on create
+	tables, _ = sortByDependencies(tables, deps)
+
+	for _, table := range tables {
+		up = append(up, create[table]+";")
+	}
+	for _, o := range others {
+		up = append(up, o.sql+";")
+	}
+
+	for i := len(others) - 1; i >= 0; i-- {
+		down = append(down, fmt.Sprintf("DROP %s IF EXISTS %s;", strings.ToUpper(others[i].kind), mig.dialect.quoteIdent(others[i].name)))
+	}
+	for i := len(tables) - 1; i >= 0; i-- {
+		down = append(down, fmt.Sprintf("DROP TABLE %s;", mig.dialect.quoteIdent(tables[i])))
+	}
+
+	return up, down, nil
+}
+
+func (mig *Mig) postgresSchema() (up []string, down []string, err error) {
+	const regclass = `(quote_ident(current_schema()) || '.' || quote_ident($1))::regclass`
+
+	sequences, err := mig.queryStrings(`
+		SELECT sequence_name
+		FROM information_schema.sequences
+		WHERE sequence_schema = current_schema()
+		ORDER BY sequence_name`,
+	)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	tables, err := mig.queryStrings(`
+		SELECT table_name
+		FROM information_schema.tables
+		WHERE table_schema = current_schema() AND table_type = 'BASE TABLE' AND table_name NOT IN ($1, $2, $3, $4, $5)
+		ORDER BY table_name`,
+		mig.tableName(),
+		mig.tableName()+repeatableTableSuffix,
+		mig.tableName()+layoutTableSuffix,
+		mig.repairName(),
+		mig.historyName(),
+	)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var (
+		create      = map[string]string{}
+		indexes     = map[string][]string{}
+		foreignKeys []string
+		deps        = map[string][]string{}
+	)
+	for _, table := range tables {
+		var columns []string
+
+		rows, err := mig.config.Db.Query(`
+			SELECT a.attname, format_type(a.atttypid, a.atttypmod), a.attnotnull, COALESCE(pg_get_expr(d.adbin, d.adrelid), '')
+			FROM pg_attribute a
+			LEFT JOIN pg_attrdef d ON d.adrelid = a.attrelid AND d.adnum = a.attnum
+			WHERE a.attrelid = `+regclass+` AND a.attnum > 0 AND NOT a.attisdropped
+			ORDER BY a.attnum`,
+			table,
+		)
+		if err != nil {
+			return nil, nil, err
+		}
+		for rows.Next() {
+			var (
+				name, typ, def string
+				notNull        bool
+			)
+			err = rows.Scan(&name, &typ, &notNull, &def)
+			if err != nil {
+				rows.Close()
+				return nil, nil, err
+			}
+
+			column := mig.dialect.quoteIdent(name) + " " + typ
+			if def != "" {
+				column += " DEFAULT " + def
+			}
+			if notNull {
+				column += " NOT NULL"
+			}
+			columns = append(columns, column)
+		}
+		rows.Close()
+		if err = rows.Err(); err != nil {
+			return nil, nil, err
+		}
+
+		rows, err = mig.config.Db.Query(`
+			SELECT c.conname, c.contype, pg_get_constraintdef(c.oid), COALESCE(r.relname, '')
+			FROM pg_constraint c
+			LEFT JOIN pg_class r ON r.oid = c.confrelid
+			WHERE c.conrelid = `+regclass+`
+			ORDER BY c.contype, c.conname`,
+			table,
+		)
+		if err != nil {
+			return nil, nil, err
+		}
+		for rows.Next() {
+			var name, typ, def, ref string
+			err = rows.Scan(&name, &typ, &def, &ref)
+			if err != nil {
+				rows.Close()
+				return nil, nil, err
+			}
+
+			constraint := "CONSTRAINT " + mig.dialect.quoteIdent(name) + " " + def
+			if typ != "f" {
+				columns = append(columns, constraint)
+				continue
+			}
+
+			// foreign keys are added once all tables exist, so cycles can be created
+			foreignKeys = append(foreignKeys, fmt.Sprintf("ALTER TABLE %s ADD %s;", mig.dialect.quoteIdent(table), constraint))
+			deps[table] = append(deps[table], ref)
+		}
+		rows.Close()
+		if err = rows.Err(); err != nil {
+			return nil, nil, err
+		}
+
+		create[table] = fmt.Sprintf(
+			"CREATE TABLE %s (\n    %s\n);",
+			mig.dialect.quoteIdent(table),
+			strings.Join(columns, ",\n    "),
+		)
+
+		indexes[table], err = mig.queryStrings(`
+			SELECT indexdef || ';'
+			FROM pg_indexes
+			WHERE schemaname = current_schema() AND tablename = $1
+				AND indexname NOT IN (SELECT conname FROM pg_constraint WHERE conrelid = `+regclass+`)
+			ORDER BY indexname`,
+			table,
+		)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+
+	type view struct {
+		name, def string
+	}
+	var views []view
+	rows, err := mig.config.Db.Query(`
+		SELECT c.relname, pg_get_viewdef(c.oid, true)
+		FROM pg_class c
+		JOIN pg_namespace n ON n.oid = c.relnamespace
+		WHERE n.nspname = current_schema() AND c.relkind = 'v'
+		ORDER BY c.oid`,
+	)
+	if err != nil {
+		return nil, nil, err
+	}
+	for rows.Next() {
+		var v view
+		err = rows.Scan(&v.name, &v.def)
+		if err != nil {
+			rows.Close()
+			return nil, nil, err
+		}
+		views = append(views, v)
+	}
+	rows.Close()
+	if err = rows.Err(); err != nil {
+		return nil, nil, err
+	}
+
+	// foreign keys are created separately, so the order only matters for dropping
+	tables, _ = sortByDependencies(tables, deps)
+
+	for _, sequence := range sequences {
+		up = append(up, fmt.Sprintf("CREATE SEQUENCE %s;", mig.dialect.quoteIdent(sequence)))
+	}
+	for _, table := range tables {
+		up = append(up, create[table])
+		up = append(up, indexes[table]...)
+	}
+	up = append(up, foreignKeys...)
+	for _, v := range views {
+		up = append(up, fmt.Sprintf("CREATE VIEW %s AS\n%s;", mig.dialect.quoteIdent(v.name), strings.TrimSuffix(strings.TrimSpace(v.def), ";")))
+	}
+
+	for i := len(views) - 1; i >= 0; i-- {
+		down = append(down, fmt.Sprintf("DROP VIEW IF EXISTS %s;", mig.dialect.quoteIdent(views[i].name)))
+	}
+	for i := len(tables) - 1; i >= 0; i-- {
+		down = append(down, fmt.Sprintf("DROP TABLE IF EXISTS %s CASCADE;", mig.dialect.quoteIdent(tables[i])))
+	}
+	for i := len(sequences) - 1; i >= 0; i-- {
+		// sequences owned by serial columns are already gone with their table
+		down = append(down, fmt.Sprintf("DROP SEQUENCE IF EXISTS %s;", mig.dialect.quoteIdent(sequences[i])))
+	}
+
+	return up, down, nil
+}
+
+// queryStrings runs a query returning a single text column and collects the values
+func (mig *Mig) queryStrings(query string, args ...any) ([]string, error) {
+	rows, err := mig.config.Db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var result []string
+	for rows.Next() {
+		var s string
+		err = rows.Scan(&s)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, s)
+	}
+
+	return result, rows.Err()
+}
@@ -0,0 +1,95 @@
+package mig
+
+import (
+	"database/sql"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBaseline(t *testing.T) {
+	t.Run("baseline recreates sqlite schema", func(t *testing.T) {
+		testDbPath := "./test/test_baseline1.db"
+		db, err := sql.Open("sqlite3", testDbPath)
+		assert.Nil(t, err)
+		defer db.Close()
+
+		_, err = db.Exec(`
+			CREATE TABLE orders (id INTEGER PRIMARY KEY, user_id INTEGER REFERENCES users(id));
+			CREATE TABLE users (id INTEGER PRIMARY KEY, name TEXT);
+			CREATE INDEX users_name ON users (name);
+			CREATE VIEW user_names AS SELECT name FROM users;
+		`)
+		assert.Nil(t, err)
+
+		m, err := New(Config{Db: db})
+		assert.Nil(t, err)
+
+		baseline, err := m.Baseline()
+		assert.Nil(t, err)
+		assert.Equal(t, int64(1), baseline.Id)
+		assert.Equal(t, BASELINE_FILE_NAME, baseline.FileName)
+
+		assert.NotContains(t, baseline.Up, "migrations")
+		assert.Less(t, strings.Index(baseline.Up, "CREATE TABLE users"), strings.Index(baseline.Up, "CREATE TABLE orders"))
+		assert.Less(t, strings.Index(baseline.Up, "CREATE TABLE orders"), strings.Index(baseline.Up, "CREATE VIEW user_names"))
+		assert.Less(t, strings.Index(baseline.Down, `DROP VIEW IF EXISTS "user_names"`), strings.Index(baseline.Down, `DROP TABLE "orders"`))
+		assert.Less(t, strings.Index(baseline.Down, `DROP TABLE "orders"`), strings.Index(baseline.Down, `DROP TABLE "users"`))
+
+		dir := t.TempDir()
+		err = m.WriteBaseline(dir)
+		assert.Nil(t, err)
+
+		err = m.WriteBaseline(dir)
+		assert.NotNil(t, err, "existing baseline must not be overwritten")
+
+		contents, err := os.ReadFile(filepath.Join(dir, BASELINE_FILE_NAME))
+		assert.Nil(t, err)
+		assert.True(t, strings.HasPrefix(string(contents), DEFAULT_UP_DELIMITER+"\n"))
+
+		freshDbPath := "./test/test_baseline2.db"
+		fresh, err := sql.Open("sqlite3", freshDbPath)
+		assert.Nil(t, err)
+		defer fresh.Close()
+
+		m2, err := New(Config{
+			Db: fresh,
+			Fs: os.DirFS(dir),
+		})
+		assert.Nil(t, err)
+
+		err = m2.Migrate()
+		assert.Nil(t, err)
+
+		tableMustExistSqlite(t, fresh, "users")
+		tableMustExistSqlite(t, fresh, "orders")
+
+		_, err = fresh.Exec(baseline.Down)
+		assert.Nil(t, err)
+
+		tableMustNotExistSqlite(t, fresh, "users")
+		tableMustNotExistSqlite(t, fresh, "orders")
+
+		os.Remove(testDbPath)
+		os.Remove(freshDbPath)
+	})
+}
+
+func TestSortByDependencies(t *testing.T) {
+	got, err := sortByDependencies(
+		[]string{"a", "b", "c", "d"},
+		map[string][]string{"a": {"c"}, "c": {"d", "x"}},
+	)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"b", "d", "c", "a"}, got)
+
+	got, err = sortByDependencies(
+		[]string{"a", "b", "c"},
+		map[string][]string{"a": {"b"}, "b": {"a"}},
+	)
+	assert.NotNil(t, err)
+	assert.Equal(t, []string{"c", "a", "b"}, got)
+}
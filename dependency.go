@@ -0,0 +1,114 @@
+package mig
+
+import (
+	"fmt"
+	"path"
+	"strconv"
+	"strings"
+)
+
+// REQUIRES_DIRECTIVE declares the migrations a migration depends on, e.g.
+// "-- mig:requires billing/0003, 0007". It may be repeated.
+const REQUIRES_DIRECTIVE = "requires"
+
+// orderByDependencies sorts migrations, which must be sorted by id, so that
+// each comes after the migrations it requires, keeping the id order where
+// there is a choice. It fails on unknown requirements and cycles.
+func orderByDependencies(migrations []Migration) ([]Migration, error) {
+	var (
+		byId  = make(map[int64]*Migration, len(migrations))
+		names = make([]string, len(migrations))
+		deps  = map[string][]string{}
+	)
+	for i := range migrations {
+		byId[migrations[i].Id] = &migrations[i]
+		names[i] = strconv.FormatInt(migrations[i].Id, 10)
+	}
+
+	for i, m := range migrations {
+		for _, ref := range m.Requires {
+			id, err := resolveRequirement(m, ref, byId)
+			if err != nil {
+				return nil, err
+			}
+			deps[names[i]] = append(deps[names[i]], strconv.FormatInt(id, 10))
+		}
+	}
+
+	sorted, err := sortByDependencies(names, deps)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]Migration, 0, len(migrations))
+	for _, name := range sorted {
+		id, _ := strconv.ParseInt(name, 10, 64)
+		result = append(result, *byId[id])
+	}
+	return result, nil
+}
+
+// resolveRequirement finds the id of the migration ref refers to. A reference
+// is the id of a migration, optionally preceded by the directory of its file,
+// like billing/0003, or a file name starting with the id.
+func resolveRequirement(m Migration, ref string, byId map[int64]*Migration) (int64, error) {
+	dir, name := path.Split(ref)
+	dir = strings.TrimSuffix(dir, "/")
+
+	id, err := getIntFromFileName(name)
+	if err != nil {
+		return 0, fmt.Errorf("mig: %s has an invalid requirement %q: %w", migrationName(m), ref, err)
+	}
+
+	required, ok := byId[id]
+	if !ok {
+		return 0, fmt.Errorf("mig: %s requires %s, which is not in the source", migrationName(m), ref)
+	}
+	if dir != "" && path.Dir(required.FileName) != dir {
+		return 0, fmt.Errorf("mig: %s requires %s, but migration %d is %s", migrationName(m), ref, id, migrationName(*required))
+	}
+	if id == m.Id {
+		return 0, fmt.Errorf("mig: %s requires itself", migrationName(m))
+	}
+
+	return id, nil
+}
+
+// sourceOrder maps the ids of the source to their position in the order
+// migrations are applied in
+type sourceOrder map[int64]int
+
+func (mig *Mig) sourceOrder() sourceOrder {
+	order := make(sourceOrder, len(mig.config.Migrations))
+	for i, m := range mig.config.Migrations {
+		order[m.Id] = i
+	}
+	return order
+}
+
+// before reports whether the migration with id a comes before the one with
+// id b. Migrations that are no longer in the source come after all others,
+// ordered by id.
+func (o sourceOrder) before(a, b int64) bool {
+	pa, okA := o[a]
+	pb, okB := o[b]
+	switch {
+	case okA && okB:
+		return pa < pb
+	case okA != okB:
+		return okA
+	default:
+		return a < b
+	}
+}
+
+// appliedAfter reports whether any applied migration that is still in the
+// source comes after id, which makes id out of order if it is not applied
+func (o sourceOrder) appliedAfter(id int64, dbMigrations []Migration) bool {
+	for _, dbMig := range dbMigrations {
+		if _, ok := o[dbMig.Id]; ok && o.before(id, dbMig.Id) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,177 @@
+package mig
+
+import (
+	"database/sql"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOrderByDependencies(t *testing.T) {
+	ids := func(migrations []Migration) []int64 {
+		var result []int64
+		for _, m := range migrations {
+			result = append(result, m.Id)
+		}
+		return result
+	}
+
+	t.Run("required migrations come first, id order otherwise", func(t *testing.T) {
+		got, err := orderByDependencies([]Migration{
+			{Id: 1, Requires: []string{"3"}},
+			{Id: 2},
+			{Id: 3, FileName: "billing/0003_invoices.sql"},
+		})
+		assert.Nil(t, err)
+		assert.Equal(t, []int64{2, 3, 1}, ids(got))
+
+		got, err = orderByDependencies([]Migration{{Id: 1}, {Id: 2}, {Id: 3}})
+		assert.Nil(t, err)
+		assert.Equal(t, []int64{1, 2, 3}, ids(got))
+	})
+
+	t.Run("invalid requirements are rejected", func(t *testing.T) {
+		tests := []struct {
+			migrations []Migration
+			err        string
+		}{
+			{
+				[]Migration{{Id: 1, Requires: []string{"2"}}, {Id: 2, Requires: []string{"1"}}},
+				"mig: dependency cycle between 1, 2",
+			},
+			{
+				[]Migration{{Id: 1, FileName: "0001_a.sql", Requires: []string{"billing/0009"}}},
+				"mig: 0001_a.sql requires billing/0009, which is not in the source",
+			},
+			{
+				[]Migration{{Id: 1, Requires: []string{"billing/0002"}}, {Id: 2, FileName: "orders/0002_b.sql"}},
+				"mig: migration 1 requires billing/0002, but migration 2 is orders/0002_b.sql",
+			},
+			{
+				[]Migration{{Id: 1, Requires: []string{"billing"}}},
+				`mig: migration 1 has an invalid requirement "billing": mig: no number found in filename`,
+			},
+			{
+				[]Migration{{Id: 1, Requires: []string{"1"}}},
+				"mig: migration 1 requires itself",
+			},
+		}
+
+		for _, tt := range tests {
+			_, err := orderByDependencies(tt.migrations)
+			assert.EqualError(t, err, tt.err)
+		}
+	})
+}
+
+func TestDependencies(t *testing.T) {
+	t.Run("migrations are applied and rolled back in dependency order", func(t *testing.T) {
+		testDbPath := "./test/test_dependency1.db"
+		db, err := sql.Open("sqlite3", testDbPath)
+		assert.Nil(t, err)
+		defer db.Close()
+
+		fsys := fstest.MapFS{
+			"orders/0001_orders.sql": {Data: []byte(`-- mig:requires billing/0003
+-- up
+CREATE TABLE orders (id INTEGER PRIMARY KEY, invoice_id INTEGER REFERENCES invoices (id));
+-- down
+DROP TABLE orders;
+`)},
+			"users/0002_users.sql":      {Data: []byte("-- up\nCREATE TABLE users (id INTEGER PRIMARY KEY);\n-- down\nDROP TABLE users;\n")},
+			"billing/0003_invoices.sql": {Data: []byte("-- up\nCREATE TABLE invoices (id INTEGER PRIMARY KEY);\n-- down\nDROP TABLE invoices;\n")},
+		}
+
+		m, err := New(Config{Db: db, Fs: fsys, Recursive: true})
+		assert.Nil(t, err)
+		assert.Equal(t, []string{"billing/0003"}, m.config.Migrations[2].Requires)
+
+		err = m.Migrate()
+		assert.Nil(t, err)
+
+		status, err := m.Status()
+		assert.Nil(t, err)
+		assert.Equal(t, []int64{3, 1, 2}, []int64{status[0].AppliedOrder, status[1].AppliedOrder, status[2].AppliedOrder})
+		for _, s := range status {
+			assert.False(t, s.OutOfOrder)
+		}
+
+		err = m.Migrate()
+		assert.Nil(t, err, "migrating again must not fail on the id order")
+
+		// the requirements are stored and exported with the migration
+		dir := t.TempDir()
+		err = m.ExportApplied(dir)
+		assert.Nil(t, err)
+		exported, err := os.ReadFile(filepath.Join(dir, "orders", "0001_orders.sql"))
+		assert.Nil(t, err)
+		assert.Contains(t, string(exported), "-- mig:requires billing/0003\n")
+
+		// changing invoices rolls back orders, which requires it, and applies both again
+		fsys["billing/0003_invoices.sql"] = &fstest.MapFile{Data: []byte("-- up\nCREATE TABLE invoices (id INTEGER PRIMARY KEY, total INTEGER);\n-- down\nDROP TABLE invoices;\n")}
+		m, err = New(Config{Db: db, Fs: fsys, Recursive: true})
+		assert.Nil(t, err)
+		err = m.Migrate()
+		assert.Nil(t, err)
+
+		status, err = m.Status()
+		assert.Nil(t, err)
+		assert.Equal(t, []int64{3, 1, 2}, []int64{status[0].AppliedOrder, status[1].AppliedOrder, status[2].AppliedOrder})
+		_, err = db.Exec("SELECT total FROM invoices")
+		assert.Nil(t, err)
+
+		// removing every migration rolls back orders before invoices
+		m.config.Migrations = nil
+		err = m.Migrate()
+		assert.Nil(t, err)
+		assert.Equal(t, 0, countRows(t, db, "migrations"))
+
+		err = os.Remove(testDbPath)
+		assert.Nil(t, err)
+	})
+
+	t.Run("removing a migration rolls back the ones applied after it", func(t *testing.T) {
+		testDbPath := "./test/test_dependency2.db"
+		db, err := sql.Open("sqlite3", testDbPath)
+		assert.Nil(t, err)
+		defer db.Close()
+
+		migrations := []Migration{
+			{Id: 1, Up: "CREATE TABLE test1 (id INTEGER PRIMARY KEY);", Down: "DROP TABLE test1;"},
+			{Id: 2, Up: "CREATE TABLE test2 (id INTEGER PRIMARY KEY);", Down: "DROP TABLE test2;"},
+			{Id: 3, Up: "CREATE VIEW test3 AS SELECT id FROM test2;", Down: "DROP VIEW test3;"},
+		}
+		m, err := New(Config{Db: db, Migrations: migrations})
+		assert.Nil(t, err)
+		err = m.Migrate()
+		assert.Nil(t, err)
+
+		m, err = New(Config{Db: db, Migrations: []Migration{migrations[0], migrations[2]}})
+		assert.Nil(t, err)
+		err = m.Migrate()
+		assert.Nil(t, err)
+
+		history, err := m.History()
+		assert.Nil(t, err)
+		var operations []string
+		for _, e := range history[3:] {
+			operations = append(operations, fmt.Sprintf("%s %d", e.Operation, e.Id))
+		}
+		assert.Equal(t, []string{"down 3", "down 2", "up 3"}, operations)
+		tableMustNotExistSqlite(t, db, "test2")
+		tableMustExistSqlite(t, db, "test1")
+
+		err = os.Remove(testDbPath)
+		assert.Nil(t, err)
+	})
+
+	t.Run("requirements can be repeated and listed", func(t *testing.T) {
+		m, err := MigFormat("", "")("-- mig:requires 1, 2\n-- mig:requires billing/0003\n-- up\n-- down\n")
+		assert.Nil(t, err)
+		assert.Equal(t, []string{"1", "2", "billing/0003"}, m.Requires)
+	})
+}
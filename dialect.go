@@ -0,0 +1,108 @@
+package mig
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+)
+
+// dialect identifies the SQL flavor of the configured database
+type dialect int
+
+const (
+	dialectUnknown dialect = iota
+	dialectSqlite
+	dialectPostgres
+	dialectMysql
+	dialectMssql
+)
+
+func (d dialect) String() string {
+	switch d {
+	case dialectSqlite:
+		return "sqlite"
+	case dialectPostgres:
+		return "postgres"
+	case dialectMysql:
+		return "mysql"
+	case dialectMssql:
+		return "mssql"
+	}
+	return "unknown"
+}
+
+// detectDialect guesses the dialect from the type of the driver behind db,
+// e.g. *sqlite3.SQLiteDriver or *pq.Driver.
+func detectDialect(db *sql.DB) dialect {
+	if db == nil {
+		return dialectUnknown
+	}
+
+	name := strings.ToLower(fmt.Sprintf("%T", db.Driver()))
+	switch {
+	case strings.Contains(name, "sqlite"):
+		return dialectSqlite
+	case strings.Contains(name, "pq."), strings.Contains(name, "pgx"), strings.Contains(name, "postgres"):
+		return dialectPostgres
+	case strings.Contains(name, "mysql"):
+		return dialectMysql
+	case strings.Contains(name, "mssql"), strings.Contains(name, "sqlserver"):
+		return dialectMssql
+	}
+	return dialectUnknown
+}
+
+// quoteIdent quotes an identifier for use in a statement
+func (d dialect) quoteIdent(name string) string {
+	switch d {
+	case dialectMysql:
+		return "`" + strings.ReplaceAll(name, "`", "``") + "`"
+	case dialectMssql:
+		return "[" + strings.ReplaceAll(name, "]", "]]") + "]"
+	}
+	return `"` + strings.ReplaceAll(name, `"`, `""`) + `"`
+}
+
+// lockTable returns a statement that locks table until the end of the
+// transaction, against writes and other lockTable statements
+func (d dialect) lockTable(table string) string {
+	switch d {
+	case dialectPostgres:
+		return fmt.Sprintf("LOCK TABLE %s IN SHARE ROW EXCLUSIVE MODE", table)
+	case dialectMysql:
+		return fmt.Sprintf("SELECT * FROM %s FOR UPDATE", table)
+	case dialectMssql:
+		return fmt.Sprintf("SELECT COUNT(*) FROM %s WITH (TABLOCKX, HOLDLOCK)", table)
+	}
+	// sqlite locks the whole database for the first write of a transaction
+	return fmt.Sprintf("DELETE FROM %s WHERE 1 = 0", table)
+}
+
+// renameTable returns a statement that renames the quoted table to name,
+// keeping its schema
+func (d dialect) renameTable(table string, name string) string {
+	if d == dialectMssql {
+		return fmt.Sprintf("EXEC sp_rename '%s', '%s'", strings.ReplaceAll(table, "'", "''"), strings.ReplaceAll(name, "'", "''"))
+	}
+	return fmt.Sprintf("ALTER TABLE %s RENAME TO %s", table, d.quoteIdent(name))
+}
+
+// tableExists returns a query counting the tables called name in schema, the
+// default schema of the connection if empty
+func (d dialect) tableExists(name, schema string) (string, []any) {
+	if d == dialectSqlite {
+		return "SELECT COUNT(*) FROM sqlite_master WHERE type = 'table' AND name = $1", []any{name}
+	}
+
+	current := "current_schema()"
+	switch d {
+	case dialectMysql:
+		current = "DATABASE()"
+	case dialectMssql:
+		current = "SCHEMA_NAME()"
+	}
+	return fmt.Sprintf(
+		"SELECT COUNT(*) FROM information_schema.tables WHERE table_name = $1 AND table_schema = COALESCE(NULLIF($2, ''), %s)",
+		current,
+	), []any{name, schema}
+}
@@ -0,0 +1,137 @@
+package mig
+
+import (
+	"fmt"
+	"strings"
+)
+
+const diffContextLines = 3
+
+// unifiedDiff returns a line based unified diff turning a into b, or an empty
+// string if they are equal
+func unifiedDiff(aName, bName, a, b string) string {
+	if a == b {
+		return ""
+	}
+
+	aLines := splitLines(a)
+	bLines := splitLines(b)
+	ops := diffLines(aLines, bLines)
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "--- %s\n+++ %s\n", aName, bName)
+
+	for start := 0; start < len(ops); {
+		// find the next change
+		for start < len(ops) && ops[start].kind == ' ' {
+			start++
+		}
+		if start == len(ops) {
+			break
+		}
+
+		// extend the hunk until there is a run of unchanged lines long enough to split on
+		end := start
+		for end < len(ops) {
+			if ops[end].kind != ' ' {
+				end++
+				continue
+			}
+			run := end
+			for run < len(ops) && ops[run].kind == ' ' {
+				run++
+			}
+			if run == len(ops) || run-end > 2*diffContextLines {
+				break
+			}
+			end = run
+		}
+
+		hunkStart := max(start-diffContextLines, 0)
+		hunkEnd := min(end+diffContextLines, len(ops))
+
+		aStart, bStart, aCount, bCount := ops[hunkStart].aLine, ops[hunkStart].bLine, 0, 0
+		for _, op := range ops[hunkStart:hunkEnd] {
+			if op.kind != '+' {
+				aCount++
+			}
+			if op.kind != '-' {
+				bCount++
+			}
+		}
+		fmt.Fprintf(&sb, "@@ -%s +%s @@\n", hunkRange(aStart, aCount), hunkRange(bStart, bCount))
+		for _, op := range ops[hunkStart:hunkEnd] {
+			sb.WriteByte(op.kind)
+			sb.WriteString(op.text)
+			sb.WriteByte('\n')
+		}
+
+		start = hunkEnd
+	}
+
+	return sb.String()
+}
+
+type diffOp struct {
+	kind byte // ' ', '-' or '+'
+	text string
+
+	// 1 based line numbers of the op in a and b, for hunk headers
+	aLine int
+	bLine int
+}
+
+// diffLines computes a minimal edit script between a and b using the longest
+// common subsequence. Migrations are small, so quadratic memory is fine.
+func diffLines(a, b []string) []diffOp {
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	var ops []diffOp
+	i, j := 0, 0
+	for i < len(a) || j < len(b) {
+		switch {
+		case i < len(a) && j < len(b) && a[i] == b[j]:
+			ops = append(ops, diffOp{' ', a[i], i + 1, j + 1})
+			i++
+			j++
+		case j == len(b) || (i < len(a) && lcs[i+1][j] >= lcs[i][j+1]):
+			ops = append(ops, diffOp{'-', a[i], i + 1, j + 1})
+			i++
+		default:
+			ops = append(ops, diffOp{'+', b[j], i + 1, j + 1})
+			j++
+		}
+	}
+
+	return ops
+}
+
+func splitLines(s string) []string {
+	if s == "" {
+		return nil
+	}
+	return strings.Split(strings.TrimSuffix(s, "\n"), "\n")
+}
+
+func hunkRange(start, count int) string {
+	if count == 0 {
+		// an empty range points at the line before it
+		return fmt.Sprintf("%d,0", start-1)
+	}
+	if count == 1 {
+		return fmt.Sprint(start)
+	}
+	return fmt.Sprintf("%d,%d", start, count)
+}
@@ -0,0 +1,62 @@
+package mig
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUnifiedDiff(t *testing.T) {
+	t.Run("equal inputs give no diff", func(t *testing.T) {
+		assert.Equal(t, "", unifiedDiff("a", "b", "same\ntext", "same\ntext"))
+	})
+
+	t.Run("changed line is shown with context", func(t *testing.T) {
+		a := "-- up\nCREATE TABLE users (id INTEGER);\n-- down\nDROP TABEL users;"
+		b := "-- up\nCREATE TABLE users (id INTEGER);\n-- down\nDROP TABLE users;"
+
+		expected := `--- db/1
++++ source/1_users.sql
+@@ -1,4 +1,4 @@
+ -- up
+ CREATE TABLE users (id INTEGER);
+ -- down
+-DROP TABEL users;
++DROP TABLE users;
+`
+		assert.Equal(t, expected, unifiedDiff("db/1", "source/1_users.sql", a, b))
+	})
+
+	t.Run("distant changes get separate hunks", func(t *testing.T) {
+		a := "1\n2\n3\n4\n5\n6\n7\n8\n9\n10"
+		b := "one\n2\n3\n4\n5\n6\n7\n8\n9\nten\n11"
+
+		expected := `--- a
++++ b
+@@ -1,4 +1,4 @@
+-1
++one
+ 2
+ 3
+ 4
+@@ -7,4 +7,5 @@
+ 7
+ 8
+ 9
+-10
++ten
++11
+`
+		assert.Equal(t, expected, unifiedDiff("a", "b", a, b))
+	})
+
+	t.Run("diff against empty input", func(t *testing.T) {
+		expected := `--- a
++++ b
+@@ -0,0 +1,2 @@
++x
++y
+`
+		assert.Equal(t, expected, unifiedDiff("a", "b", "", "x\ny"))
+	})
+}
@@ -0,0 +1,87 @@
+package mig
+
+import (
+	"fmt"
+	"runtime/debug"
+	"time"
+)
+
+// HASH_ALGORITHM names the algorithm of the stored migration hashes
+const HASH_ALGORITHM = "fnv32a"
+
+// MODULE_PATH is the module mig is built from, used to find its version
+const MODULE_PATH = "github.com/cprosche/mig"
+
+// Execution describes when, how fast and by whom a migration was applied. It
+// is recorded with every applied migration.
+type Execution struct {
+	AppliedAt time.Time
+	Duration  time.Duration
+
+	// AppliedBy is user@host, followed by Config.ApplicationName in
+	// parentheses if set
+	AppliedBy string
+
+	// MigVersion is the version of mig that applied the migration
+	MigVersion string
+
+	// HashAlgorithm is the algorithm the stored hash was computed with
+	HashAlgorithm string
+}
+
+// execution describes a migration applied now that started at start
+func (mig *Mig) execution(start time.Time) Execution {
+	return Execution{
+		AppliedAt:     start.UTC().Truncate(time.Second),
+		Duration:      time.Since(start),
+		AppliedBy:     mig.actor(),
+		MigVersion:    migVersion(),
+		HashAlgorithm: HASH_ALGORITHM,
+	}
+}
+
+// actor describes who is running mig, for the migrations table and audit records
+func (mig *Mig) actor() string {
+	if mig.config.ApplicationName == "" {
+		return currentActor()
+	}
+	return fmt.Sprintf("%s (%s)", currentActor(), mig.config.ApplicationName)
+}
+
+// migVersion returns the version of the mig module in the running binary,
+// "(devel)" when mig itself is the main module
+func migVersion() string {
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return "unknown"
+	}
+	if info.Main.Path == MODULE_PATH {
+		return info.Main.Version
+	}
+	for _, dep := range info.Deps {
+		if dep.Path != MODULE_PATH {
+			continue
+		}
+		if dep.Replace != nil && dep.Replace.Version != "" {
+			return dep.Replace.Version
+		}
+		return dep.Version
+	}
+	return "unknown"
+}
+
+// scanExecution fills e from the stored columns of the migrations table, rows
+// recorded without them keep the zero values
+func scanExecution(e *Execution, appliedAt string, executionMs int64) error {
+	e.Duration = time.Duration(executionMs) * time.Millisecond
+	if appliedAt == "" {
+		return nil
+	}
+
+	t, err := time.Parse(time.RFC3339, appliedAt)
+	if err != nil {
+		return fmt.Errorf("mig: invalid applied_at %q: %w", appliedAt, err)
+	}
+	e.AppliedAt = t
+	return nil
+}
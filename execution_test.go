@@ -0,0 +1,68 @@
+package mig
+
+import (
+	"context"
+	"database/sql"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExecution(t *testing.T) {
+	t.Run("applied migrations record when, how fast and by whom", func(t *testing.T) {
+		testDbPath := "./test/test_execution1.db"
+		db, err := sql.Open("sqlite3", testDbPath)
+		assert.Nil(t, err)
+		defer db.Close()
+
+		m, err := New(Config{
+			Db:              db,
+			ApplicationName: "billing",
+			Migrations: []Migration{
+				{Id: 1, Up: "CREATE TABLE test1 (id INTEGER PRIMARY KEY);", Down: "DROP TABLE test1;"},
+				{
+					Id:      2,
+					Version: "v1",
+					UpFunc: func(ctx context.Context, tx *sql.Tx) error {
+						time.Sleep(20 * time.Millisecond)
+						return nil
+					},
+					DownFunc: func(ctx context.Context, tx *sql.Tx) error { return nil },
+				},
+			},
+		})
+		assert.Nil(t, err)
+
+		before := time.Now().UTC().Truncate(time.Second)
+		err = m.Migrate()
+		assert.Nil(t, err)
+
+		status, err := m.Status()
+		assert.Nil(t, err)
+		for _, s := range status {
+			assert.False(t, s.Execution.AppliedAt.Before(before))
+			assert.False(t, s.Execution.AppliedAt.After(time.Now()))
+			assert.True(t, strings.HasSuffix(s.Execution.AppliedBy, " (billing)"), s.Execution.AppliedBy)
+			assert.Contains(t, s.Execution.AppliedBy, "@")
+			assert.NotEmpty(t, s.Execution.MigVersion)
+			assert.Equal(t, HASH_ALGORITHM, s.Execution.HashAlgorithm)
+		}
+		assert.GreaterOrEqual(t, status[1].Execution.Duration, 20*time.Millisecond)
+
+		err = os.Remove(testDbPath)
+		assert.Nil(t, err)
+	})
+
+	t.Run("rows without an execution keep the zero values", func(t *testing.T) {
+		var e Execution
+		err := scanExecution(&e, "", 0)
+		assert.Nil(t, err)
+		assert.Equal(t, Execution{}, e)
+
+		err = scanExecution(&e, "yesterday", 0)
+		assert.EqualError(t, err, `mig: invalid applied_at "yesterday": parsing time "yesterday" as "2006-01-02T15:04:05Z07:00": cannot parse "yesterday" as "2006"`)
+	})
+}
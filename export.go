@@ -0,0 +1,180 @@
+package mig
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// ExportApplied writes every applied migration in the migrations table back
+// out to dir, one file per migration using the configured delimiters and
+// starting with its description, metadata and requirements. Applied repeatable
+// migrations are written as R__ files holding their SQL. This reconstructs
+// the migration files of a database when the originals are lost. Go
+// migrations are skipped, and existing files are never overwritten.
+func (mig *Mig) ExportApplied(dir string) error {
+	dbMigrations, err := mig.getMigrationsFromDB()
+	if err != nil {
+		return fmt.Errorf("mig: error getting migrations from db: %w", err)
+	}
+
+	for _, m := range dbMigrations {
+		if isGoRaw(m.raw) {
+			// there is no text to restore a go migration from
+			continue
+		}
+
+		name := exportFileName(m, mig.config.IDParser)
+		err = exportFile(dir, name, m.header()+getSqlRaw(m, mig.config.UpDelimiter, mig.config.DownDelimiter)+"\n")
+		if err != nil {
+			return fmt.Errorf("mig: error exporting migration %d: %w", m.Id, err)
+		}
+	}
+
+	repeatables, err := mig.getRepeatablesFromDB()
+	if err != nil {
+		return fmt.Errorf("mig: error getting repeatable migrations from db: %w", err)
+	}
+
+	names := make([]string, 0, len(repeatables))
+	for name := range repeatables {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		m := repeatables[name]
+		// repeatable files are read without directives, so only the SQL is kept
+		err = exportFile(dir, exportRepeatableName(m.FileName), m.Up+"\n")
+		if err != nil {
+			return fmt.Errorf("mig: error exporting repeatable migration %s: %w", m.FileName, err)
+		}
+	}
+
+	return nil
+}
+
+// exportFile creates the file name in dir with contents, failing if it exists
+func exportFile(dir, name, contents string) error {
+	// the file name comes from the database, which may not be trusted
+	if !fs.ValidPath(name) || !filepath.IsLocal(filepath.FromSlash(name)) {
+		return fmt.Errorf("file name %q is outside the export directory", name)
+	}
+	target := filepath.Join(dir, filepath.FromSlash(name))
+
+	err := os.MkdirAll(filepath.Dir(target), 0o755)
+	if err != nil {
+		return err
+	}
+
+	f, err := os.OpenFile(target, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o644)
+	if err != nil {
+		return err
+	}
+
+	_, err = f.WriteString(contents)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
+
+// VerifyApplied compares the applied migrations with the migrations in the
+// root of fsys, e.g. a folder written by ExportApplied or the application's
+// own migrations. It returns an error listing every migration, versioned or
+// repeatable, that is changed, missing from fsys or not applied, with a diff
+// for changed ones. Applied Go migrations are not expected in fsys.
+func (mig *Mig) VerifyApplied(fsys fs.FS) error {
+	source := mig.fsSource(fsys, "")
+	migrations, err := readSource(source)
+	if err != nil {
+		return fmt.Errorf("mig: error getting migrations from fs: %w", err)
+	}
+	for i := range migrations {
+		migrations[i].raw = getSqlRaw(migrations[i], mig.config.UpDelimiter, mig.config.DownDelimiter)
+		migrations[i].hash = hashRaw(migrations[i].raw)
+	}
+	repeatables, err := source.Repeatables()
+	if err != nil {
+		return fmt.Errorf("mig: error getting repeatable migrations from fs: %w", err)
+	}
+	for i := range repeatables {
+		repeatables[i].raw = repeatables[i].Up
+		repeatables[i].hash = hashRaw(repeatables[i].raw)
+	}
+
+	status, err := mig.statusAgainst(migrations)
+	if err != nil {
+		return err
+	}
+	repeatableStatus, err := mig.repeatableStatus(repeatables)
+	if err != nil {
+		return err
+	}
+
+	var problems []string
+	for _, s := range status {
+		switch {
+		case s.Missing && s.Go:
+			continue
+		case s.Missing:
+			problems = append(problems, fmt.Sprintf("migration %d is applied but missing from fs", s.Id))
+		case !s.Applied:
+			problems = append(problems, fmt.Sprintf("migration %d (%s) is in fs but not applied", s.Id, s.FileName))
+		case s.Changed:
+			problems = append(problems, fmt.Sprintf("migration %d (%s) differs from the applied one:\n%s", s.Id, s.FileName, s.Diff))
+		}
+	}
+	for _, s := range repeatableStatus {
+		switch {
+		case s.Missing:
+			problems = append(problems, fmt.Sprintf("repeatable migration %s is applied but missing from fs", s.FileName))
+		case !s.Applied:
+			problems = append(problems, fmt.Sprintf("repeatable migration %s is in fs but not applied", s.FileName))
+		case s.Changed:
+			problems = append(problems, fmt.Sprintf("repeatable migration %s differs from the applied one:\n%s", s.FileName, s.Diff))
+		}
+	}
+	if len(problems) > 0 {
+		return errors.New("mig: applied migrations do not match fs:\n" + strings.Join(problems, "\n"))
+	}
+
+	return nil
+}
+
+// exportFileName names the exported file of an applied migration <id>_<filename>,
+// unless parse already finds the id in the stored file name. Exports are always
+// single .sql files, also for migrations read from up/down files or folders.
+func exportFileName(m Migration, parse IDParser) string {
+	if m.FileName == "" {
+		return fmt.Sprintf("%d_migration%s", m.Id, DEFAULT_EXTENSION)
+	}
+
+	name := strings.TrimSuffix(m.FileName, DEFAULT_EXTENSION)
+	name = strings.TrimSuffix(name, UP_FILE_SUFFIX) + DEFAULT_EXTENSION
+
+	if parse == nil {
+		parse = LeadingIntIDParser
+	}
+	if id, _, err := parse(path.Base(name)); err == nil && id == m.Id {
+		return name
+	}
+	return fmt.Sprintf("%d_%s", m.Id, name)
+}
+
+// exportRepeatableName names the exported file of an applied repeatable
+// migration, which must start with R__ to be read as repeatable again
+func exportRepeatableName(name string) string {
+	if path.Ext(name) == "" {
+		name += DEFAULT_EXTENSION
+	}
+	if !strings.HasPrefix(path.Base(name), REPEATABLE_PREFIX) {
+		name = path.Join(path.Dir(name), REPEATABLE_PREFIX+path.Base(name))
+	}
+	return name
+}
@@ -0,0 +1,149 @@
+package mig
+
+import (
+	"database/sql"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExportApplied(t *testing.T) {
+	t.Run("exported migrations match the applied ones", func(t *testing.T) {
+		testDbPath := "./test/test_export1.db"
+		db, err := sql.Open("sqlite3", testDbPath)
+		assert.Nil(t, err)
+		defer db.Close()
+
+		m, err := New(Config{
+			Db: db,
+			Fs: os.DirFS("./test/migrations1"),
+		})
+		assert.Nil(t, err)
+
+		err = m.Migrate()
+		assert.Nil(t, err)
+
+		dir := t.TempDir()
+		err = m.ExportApplied(dir)
+		assert.Nil(t, err)
+
+		for _, name := range []string{"1_file_1.sql", "2_file_2.sql", "3 - file 3.sql"} {
+			_, err = os.Stat(filepath.Join(dir, name))
+			assert.Nil(t, err, "%s must be exported", name)
+		}
+
+		err = m.VerifyApplied(os.DirFS(dir))
+		assert.Nil(t, err)
+
+		err = m.VerifyApplied(os.DirFS("./test/migrations1"))
+		assert.Nil(t, err)
+
+		err = m.ExportApplied(dir)
+		assert.NotNil(t, err, "existing files must not be overwritten")
+
+		err = os.WriteFile(filepath.Join(dir, "2_file_2.sql"), []byte("-- up\nCREATE TABLE other (id INTEGER);\n-- down\nDROP TABLE other;\n"), 0o644)
+		assert.Nil(t, err)
+		err = os.Remove(filepath.Join(dir, "3 - file 3.sql"))
+		assert.Nil(t, err)
+		err = os.WriteFile(filepath.Join(dir, "4_extra.sql"), []byte("-- up\nSELECT 1;\n-- down\nSELECT 1;\n"), 0o644)
+		assert.Nil(t, err)
+
+		err = m.VerifyApplied(os.DirFS(dir))
+		assert.NotNil(t, err)
+		assert.Contains(t, err.Error(), "+CREATE TABLE other (id INTEGER);")
+		assert.Contains(t, err.Error(), "migration 3 is applied but missing from fs")
+		assert.Contains(t, err.Error(), "migration 4 (4_extra.sql) is in fs but not applied")
+
+		os.Remove(testDbPath)
+	})
+
+	t.Run("file names outside the export directory are rejected", func(t *testing.T) {
+		testDbPath := "./test/test_export2.db"
+		db, err := sql.Open("sqlite3", testDbPath)
+		assert.Nil(t, err)
+		defer db.Close()
+
+		m, err := New(Config{Db: db, Migrations: []Migration{
+			{Id: 1, FileName: "0001_users.sql", Up: "CREATE TABLE users (id INTEGER PRIMARY KEY);", Down: "DROP TABLE users;"},
+		}})
+		assert.Nil(t, err)
+		err = m.Migrate()
+		assert.Nil(t, err)
+
+		root := t.TempDir()
+		dir := filepath.Join(root, "a", "b")
+		for _, name := range []string{"../../0001_x.sql", "/tmp/0001_x.sql", "a/../../0001_x.sql"} {
+			_, err = db.Exec("UPDATE migrations SET filename = $1", name)
+			assert.Nil(t, err)
+
+			err = m.ExportApplied(dir)
+			assert.ErrorContains(t, err, "is outside the export directory", name)
+		}
+		_, err = os.Stat(filepath.Join(root, "0001_x.sql"))
+		assert.True(t, os.IsNotExist(err))
+
+		err = os.Remove(testDbPath)
+		assert.Nil(t, err)
+	})
+
+	t.Run("applied repeatable migrations are exported and verified", func(t *testing.T) {
+		testDbPath := "./test/test_export3.db"
+		db, err := sql.Open("sqlite3", testDbPath)
+		assert.Nil(t, err)
+		defer db.Close()
+
+		m, err := New(Config{Db: db, Migrations: []Migration{
+			{Id: 1, FileName: "0001_users.sql", Up: "CREATE TABLE users (id INTEGER PRIMARY KEY);", Down: "DROP TABLE users;"},
+			{FileName: "R__user_ids.sql", Up: "DROP VIEW IF EXISTS user_ids; CREATE VIEW user_ids AS SELECT id FROM users;", Repeatable: true},
+			{FileName: "user_count", Up: "DROP VIEW IF EXISTS user_count; CREATE VIEW user_count AS SELECT COUNT(*) AS n FROM users;", Repeatable: true},
+		}})
+		assert.Nil(t, err)
+		err = m.Migrate()
+		assert.Nil(t, err)
+
+		dir := t.TempDir()
+		err = m.ExportApplied(dir)
+		assert.Nil(t, err)
+
+		contents, err := os.ReadFile(filepath.Join(dir, "R__user_ids.sql"))
+		assert.Nil(t, err)
+		assert.Equal(t, "DROP VIEW IF EXISTS user_ids; CREATE VIEW user_ids AS SELECT id FROM users;\n", string(contents))
+		_, err = os.Stat(filepath.Join(dir, "R__user_count.sql"))
+		assert.Nil(t, err)
+
+		_, err = db.Exec("UPDATE migrations_repeatable SET filename = $1 WHERE filename = $2", "R__user_count.sql", "user_count")
+		assert.Nil(t, err)
+		err = m.VerifyApplied(os.DirFS(dir))
+		assert.Nil(t, err)
+
+		err = os.WriteFile(filepath.Join(dir, "R__user_ids.sql"), []byte("CREATE VIEW user_ids AS SELECT 1 AS id;\n"), 0o644)
+		assert.Nil(t, err)
+		err = os.Remove(filepath.Join(dir, "R__user_count.sql"))
+		assert.Nil(t, err)
+		err = os.WriteFile(filepath.Join(dir, "R__extra.sql"), []byte("SELECT 1;\n"), 0o644)
+		assert.Nil(t, err)
+
+		err = m.VerifyApplied(os.DirFS(dir))
+		assert.NotNil(t, err)
+		assert.Contains(t, err.Error(), "+CREATE VIEW user_ids AS SELECT 1 AS id;")
+		assert.Contains(t, err.Error(), "repeatable migration R__user_count.sql is applied but missing from fs")
+		assert.Contains(t, err.Error(), "repeatable migration R__extra.sql is in fs but not applied")
+
+		err = os.Remove(testDbPath)
+		assert.Nil(t, err)
+	})
+
+	t.Run("migrations without a file name are named by id", func(t *testing.T) {
+		assert.Equal(t, "7_migration.sql", exportFileName(Migration{Id: 7}, nil))
+		assert.Equal(t, "0007_users.sql", exportFileName(Migration{Id: 7, FileName: "0007_users.sql"}, nil))
+		assert.Equal(t, "7_users.sql", exportFileName(Migration{Id: 7, FileName: "users.sql"}, nil))
+		assert.Equal(t, "0007_users.sql", exportFileName(Migration{Id: 7, FileName: "0007_users.up.sql"}, nil))
+		assert.Equal(t, "2025/0007_users.sql", exportFileName(Migration{Id: 7, FileName: "2025/0007_users"}, nil))
+		assert.Equal(t, "V7__users.sql", exportFileName(Migration{Id: 7, FileName: "V7__users.sql"}, FlywayIDParser))
+		assert.Equal(t, "R__views.sql", exportRepeatableName("R__views.sql"))
+		assert.Equal(t, "R__views.sql", exportRepeatableName("views"))
+		assert.Equal(t, "views/R__users.sql", exportRepeatableName("views/users.sql"))
+	})
+}
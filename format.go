@@ -0,0 +1,227 @@
+package mig
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Format parses the contents of a migration file into the Up and Down SQL and
+// any options set in the file, like NoTransaction. Id, FileName and
+// Description are set by the caller.
+type Format func(contents string) (Migration, error)
+
+// MigFormat returns the format of mig's own files, where the up and down SQL
+// follow lines holding the delimiters, the defaults if empty, and "-- mig:"
+// directives may come before them
+func MigFormat(upDelimiter, downDelimiter string) Format {
+	upDelimiter = defaultString(upDelimiter, DEFAULT_UP_DELIMITER)
+	downDelimiter = defaultString(downDelimiter, DEFAULT_DOWN_DELIMITER)
+
+	return func(contents string) (Migration, error) {
+		parsed, err := parseMigration(contents, upDelimiter, downDelimiter)
+		if err != nil {
+			return Migration{}, err
+		}
+
+		m := Migration{Up: parsed.up, Down: parsed.down}
+		err = applyDirectives(&m, parsed.directives)
+		if err != nil {
+			return Migration{}, err
+		}
+		return m, nil
+	}
+}
+
+// GooseFormat parses files written for goose, like
+//
+//	-- +goose NO TRANSACTION
+//	-- +goose Up
+//	-- +goose StatementBegin
+//	CREATE FUNCTION ...;
+//	-- +goose StatementEnd
+//
+//	-- +goose Down
+//	DROP FUNCTION ...;
+//
+// The Down section is optional. Like goose, mig runs each statement with its
+// own query, see Migration.SplitStatements, so the StatementBegin and
+// StatementEnd annotations are kept in the SQL. ENVSUB is not supported.
+func GooseFormat(contents string) (Migration, error) {
+	return gooseAnnotations.parse(contents)
+}
+
+// SQLMigrateFormat parses files written for sql-migrate, like
+//
+//	-- +migrate Up notransaction
+//	CREATE INDEX CONCURRENTLY ...;
+//
+//	-- +migrate Down
+//	DROP INDEX ...;
+//
+// The Down section and statement blocks are handled like in GooseFormat.
+// mig runs both sections of a migration either in a transaction or not, so
+// notransaction on either section applies to both.
+func SQLMigrateFormat(contents string) (Migration, error) {
+	return sqlMigrateAnnotations.parse(contents)
+}
+
+// annotationSyntax describes the section annotations of goose and sql-migrate
+type annotationSyntax struct {
+	// prefix starts every annotation, e.g. "-- +goose "
+	prefix string
+
+	// noTransaction is an annotation of its own disabling the transaction
+	noTransaction string
+
+	// noTransactionOption follows Up or Down to disable the transaction
+	noTransactionOption string
+}
+
+var (
+	gooseAnnotations      = annotationSyntax{prefix: "-- +goose ", noTransaction: "NO TRANSACTION"}
+	sqlMigrateAnnotations = annotationSyntax{prefix: "-- +migrate ", noTransactionOption: "notransaction"}
+)
+
+func (a annotationSyntax) parse(contents string) (Migration, error) {
+	var (
+		m                           Migration
+		up, down                    []string
+		section                     *[]string
+		upLine, downLine, blockLine int
+	)
+
+	contents = strings.TrimPrefix(contents, "\uFEFF")
+	for i, line := range strings.Split(contents, "\n") {
+		n := i + 1
+		line = strings.TrimSuffix(line, "\r")
+		trimmed := strings.TrimSpace(line)
+
+		if !strings.HasPrefix(trimmed, a.prefix) {
+			if section != nil {
+				*section = append(*section, line)
+			} else if trimmed != "" && !strings.HasPrefix(trimmed, "--") {
+				return Migration{}, &ParseError{n, fmt.Errorf("SQL before the first annotation")}
+			}
+			continue
+		}
+
+		annotation := strings.TrimSpace(strings.TrimPrefix(trimmed, a.prefix))
+		command, option, _ := strings.Cut(annotation, " ")
+		option = strings.TrimSpace(option)
+
+		switch {
+		case strings.EqualFold(annotation, a.noTransaction):
+			m.NoTransaction = true
+		case strings.EqualFold(command, "Up"), strings.EqualFold(command, "Down"):
+			if blockLine != 0 {
+				return Migration{}, &ParseError{n, fmt.Errorf("%s inside the statement block from line %d", trimmed, blockLine)}
+			}
+			if option != "" {
+				if a.noTransactionOption == "" || !strings.EqualFold(option, a.noTransactionOption) {
+					return Migration{}, &ParseError{n, fmt.Errorf("unknown option %q", option)}
+				}
+				m.NoTransaction = true
+			}
+
+			first, s := &upLine, &up
+			if strings.EqualFold(command, "Down") {
+				first, s = &downLine, &down
+			}
+			if *first != 0 {
+				return Migration{}, &ParseError{n, fmt.Errorf("duplicate %s%s annotation, first at line %d", a.prefix, command, *first)}
+			}
+			*first = n
+			section = s
+		case strings.EqualFold(annotation, "StatementBegin"):
+			if section == nil {
+				return Migration{}, &ParseError{n, fmt.Errorf("StatementBegin outside of the Up and Down sections")}
+			}
+			if blockLine != 0 {
+				return Migration{}, &ParseError{n, fmt.Errorf("StatementBegin inside the statement block from line %d", blockLine)}
+			}
+			blockLine = n
+			*section = append(*section, line)
+		case strings.EqualFold(annotation, "StatementEnd"):
+			if blockLine == 0 {
+				return Migration{}, &ParseError{n, fmt.Errorf("StatementEnd without StatementBegin")}
+			}
+			blockLine = 0
+			*section = append(*section, line)
+		default:
+			return Migration{}, &ParseError{n, fmt.Errorf("unsupported annotation %s", trimmed)}
+		}
+	}
+
+	if blockLine != 0 {
+		return Migration{}, &ParseError{blockLine, fmt.Errorf("statement block is never closed")}
+	}
+	if upLine == 0 {
+		return Migration{}, fmt.Errorf("no %sUp annotation found", a.prefix)
+	}
+
+	m.Up = strings.TrimSpace(strings.Join(up, "\n"))
+	m.Down = strings.TrimSpace(strings.Join(down, "\n"))
+	m.SplitStatements = true
+	return m, nil
+}
+
+// statementBlock reports whether line begins or ends a statement block of
+// goose or sql-migrate
+func statementBlock(line string) (begin, end bool) {
+	trimmed := strings.TrimSpace(line)
+	for _, a := range []annotationSyntax{gooseAnnotations, sqlMigrateAnnotations} {
+		if !strings.HasPrefix(trimmed, a.prefix) {
+			continue
+		}
+		annotation := strings.TrimSpace(strings.TrimPrefix(trimmed, a.prefix))
+		begin = begin || strings.EqualFold(annotation, "StatementBegin")
+		end = end || strings.EqualFold(annotation, "StatementEnd")
+	}
+	return begin, end
+}
+
+// splitStatements splits sql into statements the way goose does. A statement
+// ends with a line ending in a semicolon, and everything between StatementBegin
+// and StatementEnd is one statement. Statements holding only comments are left
+// out, and SQL after the last semicolon is a statement of its own.
+func splitStatements(sql string) []string {
+	var (
+		result  []string
+		current []string
+		inBlock bool
+	)
+
+	flush := func() {
+		statement := strings.TrimSpace(strings.Join(current, "\n"))
+		current = nil
+		for _, line := range strings.Split(statement, "\n") {
+			trimmed := strings.TrimSpace(line)
+			if trimmed != "" && !strings.HasPrefix(trimmed, "--") {
+				result = append(result, statement)
+				return
+			}
+		}
+	}
+
+	for _, line := range strings.Split(sql, "\n") {
+		line = strings.TrimSuffix(line, "\r")
+
+		begin, end := statementBlock(line)
+		switch {
+		case begin:
+			flush()
+			inBlock = true
+		case end:
+			flush()
+			inBlock = false
+		default:
+			current = append(current, line)
+			if !inBlock && strings.HasSuffix(strings.TrimSpace(line), ";") {
+				flush()
+			}
+		}
+	}
+	flush()
+
+	return result
+}
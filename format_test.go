@@ -0,0 +1,234 @@
+package mig
+
+import (
+	"context"
+	"database/sql"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMigFormat(t *testing.T) {
+	m, err := MigFormat("", "")("-- mig:no-transaction\n-- up\nCREATE INDEX CONCURRENTLY a ON b (c);\n-- down\nDROP INDEX a;")
+	assert.Nil(t, err)
+	assert.Equal(t, Migration{Up: "CREATE INDEX CONCURRENTLY a ON b (c);", Down: "DROP INDEX a;", NoTransaction: true}, m)
+
+	m, err = MigFormat("--- UP", "--- DOWN")("--- UP\nSELECT 1;\n--- DOWN\nSELECT 2;")
+	assert.Nil(t, err)
+	assert.Equal(t, Migration{Up: "SELECT 1;", Down: "SELECT 2;"}, m)
+
+	_, err = MigFormat("", "")("-- mig:no-transaction please\n-- up\n-- down\n")
+	assert.EqualError(t, err, "line 1: directive -- mig:no-transaction takes no value")
+
+	m, err = MigFormat("", "")("-- mig:split-statements\n-- up\nSELECT 1;\n-- down\nSELECT 2;")
+	assert.Nil(t, err)
+	assert.Equal(t, Migration{Up: "SELECT 1;", Down: "SELECT 2;", SplitStatements: true}, m)
+}
+
+func TestGooseFormat(t *testing.T) {
+	t.Run("sections, statement blocks and NO TRANSACTION are understood", func(t *testing.T) {
+		m, err := GooseFormat(`-- comments before the annotations are fine
+-- +goose NO TRANSACTION
+-- +goose Up
+-- +goose StatementBegin
+CREATE FUNCTION one() RETURNS integer AS $$
+BEGIN
+    RETURN 1;
+END;
+$$ LANGUAGE plpgsql;
+-- +goose StatementEnd
+
+-- +goose Down
+DROP FUNCTION one;
+`)
+		assert.Nil(t, err)
+		assert.True(t, m.NoTransaction)
+		assert.True(t, m.SplitStatements)
+		assert.Equal(t, "-- +goose StatementBegin\nCREATE FUNCTION one() RETURNS integer AS $$\nBEGIN\n    RETURN 1;\nEND;\n$$ LANGUAGE plpgsql;\n-- +goose StatementEnd", m.Up)
+		assert.Equal(t, "DROP FUNCTION one;", m.Down)
+	})
+
+	t.Run("the down section is optional", func(t *testing.T) {
+		m, err := GooseFormat("-- +goose Up\r\nSELECT 1;\r\n")
+		assert.Nil(t, err)
+		assert.Equal(t, Migration{Up: "SELECT 1;", SplitStatements: true}, m)
+	})
+
+	t.Run("errors name the line", func(t *testing.T) {
+		tests := []struct {
+			contents string
+			err      string
+		}{
+			{"SELECT 1;\n-- +goose Up\n", "line 1: SQL before the first annotation"},
+			{"-- +goose Up\n-- +goose Up\n", "line 2: duplicate -- +goose Up annotation, first at line 1"},
+			{"-- +goose StatementBegin\n-- +goose Up\n", "line 1: StatementBegin outside of the Up and Down sections"},
+			{"-- +goose Up\n-- +goose StatementBegin\n-- +goose StatementBegin\n", "line 3: StatementBegin inside the statement block from line 2"},
+			{"-- +goose Up\n-- +goose StatementEnd\n", "line 2: StatementEnd without StatementBegin"},
+			{"-- +goose Up\n-- +goose StatementBegin\nSELECT 1;\n-- +goose Down\n", "line 4: -- +goose Down inside the statement block from line 2"},
+			{"-- +goose Up\n-- +goose StatementBegin\nSELECT 1;\n", "line 2: statement block is never closed"},
+			{"-- +goose ENVSUB ON\n-- +goose Up\n", "line 1: unsupported annotation -- +goose ENVSUB ON"},
+			{"-- +goose Up notransaction\n", `line 1: unknown option "notransaction"`},
+			{"-- +goose Down\nSELECT 1;\n", "no -- +goose Up annotation found"},
+		}
+
+		for _, tt := range tests {
+			_, err := GooseFormat(tt.contents)
+			assert.EqualError(t, err, tt.err)
+		}
+	})
+}
+
+func TestSQLMigrateFormat(t *testing.T) {
+	m, err := SQLMigrateFormat(`-- +migrate Up notransaction
+CREATE INDEX CONCURRENTLY users_name ON users (name);
+
+-- +migrate Down
+DROP INDEX users_name;
+`)
+	assert.Nil(t, err)
+	assert.Equal(t, Migration{
+		Up:              "CREATE INDEX CONCURRENTLY users_name ON users (name);",
+		Down:            "DROP INDEX users_name;",
+		NoTransaction:   true,
+		SplitStatements: true,
+	}, m)
+
+	m, err = SQLMigrateFormat("-- +migrate Up\n-- +migrate StatementBegin\nSELECT 1;\n-- +migrate StatementEnd\n-- +migrate Down\nSELECT 2;\n")
+	assert.Nil(t, err)
+	assert.Equal(t, Migration{Up: "-- +migrate StatementBegin\nSELECT 1;\n-- +migrate StatementEnd", Down: "SELECT 2;", SplitStatements: true}, m)
+
+	_, err = SQLMigrateFormat("-- +migrate NO TRANSACTION\n-- +migrate Up\n")
+	assert.EqualError(t, err, "line 1: unsupported annotation -- +migrate NO TRANSACTION")
+}
+
+func TestTransactions(t *testing.T) {
+	t.Run("goose migrations are applied and rolled back", func(t *testing.T) {
+		testDbPath := "./test/test_format1.db"
+		db, err := sql.Open("sqlite3", testDbPath)
+		assert.Nil(t, err)
+		defer db.Close()
+
+		m, err := New(Config{Db: db, Fs: os.DirFS("./test/goose"), Format: GooseFormat})
+		assert.Nil(t, err)
+		assert.Len(t, m.config.Migrations, 3)
+		assert.False(t, m.config.Migrations[1].NoTransaction)
+		assert.True(t, m.config.Migrations[2].NoTransaction)
+
+		err = m.Migrate()
+		assert.Nil(t, err)
+		_, err = db.Exec("INSERT INTO users (name) VALUES ('ada')")
+		assert.Nil(t, err)
+
+		var name string
+		err = db.QueryRow("SELECT name FROM users").Scan(&name)
+		assert.Nil(t, err)
+		assert.Equal(t, "ADA", name)
+
+		m.config.Migrations = m.config.Migrations[:1]
+		err = m.Migrate()
+		assert.Nil(t, err)
+		assert.Equal(t, 1, countRows(t, db, "migrations"))
+
+		stored := Migration{raw: getSqlRaw(Migration{NoTransaction: true, SplitStatements: true}, DEFAULT_UP_DELIMITER, DEFAULT_DOWN_DELIMITER)}
+		m.storedOptions(&stored)
+		assert.True(t, stored.NoTransaction)
+		assert.True(t, stored.SplitStatements)
+
+		err = os.Remove(testDbPath)
+		assert.Nil(t, err)
+	})
+
+	t.Run("a failing migration is not partly applied", func(t *testing.T) {
+		testDbPath := "./test/test_format2.db"
+		db, err := sql.Open("sqlite3", testDbPath)
+		assert.Nil(t, err)
+		defer db.Close()
+
+		m, err := New(Config{
+			Db: db,
+			Migrations: []Migration{
+				{
+					Id:   1,
+					Up:   "CREATE TABLE test1 (id INTEGER PRIMARY KEY); CREATE TABLE test1 (id INTEGER PRIMARY KEY);",
+					Down: "DROP TABLE test1;",
+				},
+			},
+		})
+		assert.Nil(t, err)
+
+		err = m.Migrate()
+		assert.NotNil(t, err)
+		tableMustNotExistSqlite(t, db, "test1")
+		assert.Equal(t, 0, countRows(t, db, "migrations"))
+
+		m.config.Migrations[0].NoTransaction = true
+		err = m.Migrate()
+		assert.NotNil(t, err)
+		tableMustExistSqlite(t, db, "test1")
+		assert.Equal(t, 0, countRows(t, db, "migrations"))
+
+		err = os.Remove(testDbPath)
+		assert.Nil(t, err)
+	})
+
+	t.Run("go migrations cannot run outside a transaction", func(t *testing.T) {
+		err := Migration{Id: 1, UpFunc: func(ctx context.Context, tx *sql.Tx) error { return nil }, Version: "1", NoTransaction: true}.validateGo()
+		assert.EqualError(t, err, "mig: go migration 1 cannot run outside a transaction")
+	})
+}
+
+func TestSplitStatements(t *testing.T) {
+	t.Run("statements end with a semicolon at the end of a line", func(t *testing.T) {
+		statements := splitStatements(`-- create the users
+CREATE TABLE users (
+    id INTEGER PRIMARY KEY, -- the id; never reused
+    name TEXT
+);
+INSERT INTO users (name) VALUES ('a;b'); INSERT INTO users (name) VALUES ('c');
+
+-- +goose StatementBegin
+CREATE TRIGGER users_name AFTER INSERT ON users
+BEGIN
+    UPDATE users SET name = upper(NEW.name) WHERE id = NEW.id;
+END;
+-- +goose StatementEnd
+-- trailing comment
+SELECT 1`)
+		assert.Equal(t, []string{
+			"-- create the users\nCREATE TABLE users (\n    id INTEGER PRIMARY KEY, -- the id; never reused\n    name TEXT\n);",
+			"INSERT INTO users (name) VALUES ('a;b'); INSERT INTO users (name) VALUES ('c');",
+			"CREATE TRIGGER users_name AFTER INSERT ON users\nBEGIN\n    UPDATE users SET name = upper(NEW.name) WHERE id = NEW.id;\nEND;",
+			"-- trailing comment\nSELECT 1",
+		}, statements)
+	})
+
+	t.Run("comments alone are not statements", func(t *testing.T) {
+		assert.Nil(t, splitStatements("-- nothing to do\n\n"))
+		assert.Equal(t, []string{"SELECT 1;"}, splitStatements("-- +migrate StatementBegin\nSELECT 1;\n-- +migrate StatementEnd\n-- done\n"))
+	})
+
+	t.Run("each statement is run with its own query", func(t *testing.T) {
+		db := &recordingExecer{}
+		m := Migration{Up: "SELECT 1;\nSELECT 2;", SplitStatements: true}
+		err := execSQL(context.Background(), db, m, m.Up)
+		assert.Nil(t, err)
+		assert.Equal(t, []string{"SELECT 1;", "SELECT 2;"}, db.queries)
+
+		db = &recordingExecer{}
+		m.SplitStatements = false
+		err = execSQL(context.Background(), db, m, m.Up)
+		assert.Nil(t, err)
+		assert.Equal(t, []string{"SELECT 1;\nSELECT 2;"}, db.queries)
+	})
+}
+
+// recordingExecer records the queries it is asked to run
+type recordingExecer struct {
+	queries []string
+}
+
+func (e *recordingExecer) ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error) {
+	e.queries = append(e.queries, query)
+	return nil, nil
+}
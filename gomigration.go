@@ -0,0 +1,62 @@
+package mig
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+)
+
+// MigrationFunc runs a Go migration in the transaction tx
+type MigrationFunc func(ctx context.Context, tx *sql.Tx) error
+
+// goRawPrefix starts the raw text stored for Go migrations, followed by the version
+const goRawPrefix = "-- mig:go "
+
+// execer is implemented by both *sql.DB and *sql.Tx
+type execer interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+}
+
+// isGo reports whether m is a Go migration
+func (m Migration) isGo() bool {
+	return m.UpFunc != nil || m.DownFunc != nil
+}
+
+func (m Migration) validateGo() error {
+	if !m.isGo() {
+		return nil
+	}
+	if m.UpFunc == nil {
+		return fmt.Errorf("mig: go migration %d has no up function", m.Id)
+	}
+	if m.Up != "" || m.Down != "" {
+		return fmt.Errorf("mig: migration %d has both sql and go functions", m.Id)
+	}
+	if m.Version == "" {
+		return fmt.Errorf("mig: go migration %d has no version", m.Id)
+	}
+	if m.NoTransaction {
+		return fmt.Errorf("mig: go migration %d cannot run outside a transaction", m.Id)
+	}
+	return nil
+}
+
+// getGoRaw returns the text stored and hashed for a Go migration
+func getGoRaw(version string) string {
+	return goRawPrefix + version
+}
+
+func isGoRaw(raw string) bool {
+	return strings.HasPrefix(raw, goRawPrefix)
+}
+
+// findGoMigration returns the Go migration with the given id from the source
+func (mig *Mig) findGoMigration(id int64) (Migration, bool) {
+	for _, m := range mig.config.Migrations {
+		if m.Id == id && m.isGo() {
+			return m, true
+		}
+	}
+	return Migration{}, false
+}
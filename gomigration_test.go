@@ -0,0 +1,165 @@
+package mig
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGoMigrations(t *testing.T) {
+	t.Run("go migrations run in order with sql migrations", func(t *testing.T) {
+		testDbPath := "./test/test_go1.db"
+		db, err := sql.Open("sqlite3", testDbPath)
+		assert.Nil(t, err)
+		defer db.Close()
+
+		downs := 0
+		migrations := []Migration{
+			{
+				Id:      2,
+				Version: "v1",
+				UpFunc: func(ctx context.Context, tx *sql.Tx) error {
+					_, err := tx.ExecContext(ctx, "INSERT INTO users (name) VALUES ('alice'), ('bob')")
+					return err
+				},
+				DownFunc: func(ctx context.Context, tx *sql.Tx) error {
+					downs++
+					_, err := tx.ExecContext(ctx, "DELETE FROM users")
+					return err
+				},
+			},
+			{
+				Id:   1,
+				Up:   "CREATE TABLE users (id INTEGER PRIMARY KEY, name TEXT);",
+				Down: "DROP TABLE users;",
+			},
+		}
+
+		m, err := New(Config{
+			Db:         db,
+			Migrations: migrations,
+		})
+		assert.Nil(t, err)
+
+		err = m.Migrate()
+		assert.Nil(t, err)
+		assert.Equal(t, 2, countRows(t, db, "users"))
+
+		err = m.Migrate()
+		assert.Nil(t, err)
+		assert.Equal(t, 0, downs)
+
+		// a new version rolls the go migration back and applies it again
+		m.config.Migrations[1].Version = "v2"
+		err = m.Migrate()
+		assert.Nil(t, err)
+		assert.Equal(t, 1, downs)
+		assert.Equal(t, 2, countRows(t, db, "users"))
+
+		status, err := m.Status()
+		assert.Nil(t, err)
+		assert.True(t, status[1].Go)
+		assert.True(t, status[1].Applied)
+
+		os.Remove(testDbPath)
+	})
+
+	t.Run("failing go migration is not recorded", func(t *testing.T) {
+		testDbPath := "./test/test_go2.db"
+		db, err := sql.Open("sqlite3", testDbPath)
+		assert.Nil(t, err)
+		defer db.Close()
+
+		m, err := New(Config{
+			Db: db,
+			Migrations: []Migration{
+				{
+					Id:      1,
+					Version: "v1",
+					UpFunc: func(ctx context.Context, tx *sql.Tx) error {
+						_, err := tx.ExecContext(ctx, "CREATE TABLE test1 (id INTEGER PRIMARY KEY)")
+						if err != nil {
+							return err
+						}
+						return errors.New("boom")
+					},
+				},
+			},
+		})
+		assert.Nil(t, err)
+
+		err = m.Migrate()
+		assert.NotNil(t, err)
+		tableMustNotExistSqlite(t, db, "test1")
+		assert.Equal(t, 0, countRows(t, db, "migrations"))
+
+		os.Remove(testDbPath)
+	})
+
+	t.Run("removed go migration cannot be rolled back", func(t *testing.T) {
+		testDbPath := "./test/test_go3.db"
+		db, err := sql.Open("sqlite3", testDbPath)
+		assert.Nil(t, err)
+		defer db.Close()
+
+		noop := func(ctx context.Context, tx *sql.Tx) error { return nil }
+		m, err := New(Config{
+			Db: db,
+			Migrations: []Migration{
+				{Id: 1, Up: "SELECT 1;"},
+				{Id: 2, Version: "v1", UpFunc: noop},
+			},
+		})
+		assert.Nil(t, err)
+
+		err = m.Migrate()
+		assert.Nil(t, err)
+
+		m.config.Migrations = m.config.Migrations[:1]
+		err = m.Migrate()
+		assert.NotNil(t, err)
+		assert.Contains(t, err.Error(), "go migration 2 is no longer in the source")
+
+		os.Remove(testDbPath)
+	})
+
+	t.Run("invalid go migrations are rejected", func(t *testing.T) {
+		testDbPath := "./test/test_go4.db"
+		db, err := sql.Open("sqlite3", testDbPath)
+		assert.Nil(t, err)
+		defer db.Close()
+
+		noop := func(ctx context.Context, tx *sql.Tx) error { return nil }
+
+		_, err = New(Config{
+			Db:         db,
+			Migrations: []Migration{{Id: 1, UpFunc: noop}},
+		})
+		assert.NotNil(t, err, "version is required")
+
+		_, err = New(Config{
+			Db:         db,
+			Migrations: []Migration{{Id: 1, Version: "v1", UpFunc: noop, Up: "SELECT 1;"}},
+		})
+		assert.NotNil(t, err, "sql and go functions cannot be mixed")
+
+		_, err = New(Config{
+			Db:         db,
+			Migrations: []Migration{{Id: 1, Version: "v1", DownFunc: noop}},
+		})
+		assert.NotNil(t, err, "up function is required")
+
+		os.Remove(testDbPath)
+	})
+}
+
+func countRows(t *testing.T, db *sql.DB, tableName string) int {
+	var count int
+	err := db.QueryRow("SELECT COUNT(*) FROM " + tableName).Scan(&count)
+	assert.Nil(t, err)
+	return count
+}
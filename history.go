@@ -0,0 +1,223 @@
+package mig
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+const (
+	historyTableName   = "mig_history"
+	historyTableSuffix = "_history"
+)
+
+// Operations recorded in the history table
+const (
+	HISTORY_UP         = "up"
+	HISTORY_DOWN       = "down"
+	HISTORY_REPEATABLE = "repeatable"
+	HISTORY_REPAIR     = "repair"
+)
+
+// Outcomes of the operations recorded in the history table
+const (
+	OUTCOME_SUCCESS = "success"
+	OUTCOME_FAILURE = "failure"
+)
+
+var historyTableSchema = `
+		CREATE TABLE IF NOT EXISTS %s (
+			namespace TEXT NOT NULL DEFAULT '',
+			entry BIGINT,
+			operation TEXT,
+			migration_id BIGINT,
+			filename TEXT,
+			old_hash TEXT,
+			new_hash TEXT,
+			statements TEXT,
+			duration_ms BIGINT,
+			outcome TEXT,
+			error_text TEXT,
+			actor TEXT,
+			recorded_at TEXT
+		)
+`
+
+// HistoryEntry is one operation in the history table, which mig only ever
+// appends to. Unlike the migrations table it keeps rolled back and failed
+// migrations.
+type HistoryEntry struct {
+	// Entry is the position of the entry in the history table
+	Entry int64
+
+	// Operation is one of HISTORY_UP, HISTORY_DOWN, HISTORY_REPEATABLE and
+	// HISTORY_REPAIR
+	Operation string
+
+	// Id is 0 for repeatable migrations
+	Id       int64
+	FileName string
+
+	// OldHash is the hash before the operation, empty if the migration was
+	// not applied, and NewHash the hash after it, empty if it was rolled back
+	OldHash string
+	NewHash string
+
+	// SQL is what was executed, the stored raw text for Go migrations
+	SQL string
+
+	Duration time.Duration
+
+	// Outcome is OUTCOME_SUCCESS or OUTCOME_FAILURE, with the error in Error
+	Outcome string
+	Error   string
+
+	// Actor is who ran mig, see Execution.AppliedBy
+	Actor      string
+	RecordedAt time.Time
+}
+
+// historyName is the name of the history table, mig_history unless
+// Config.TableName names the tracking table otherwise
+func (mig *Mig) historyName() string {
+	if mig.tableName() == migrationTableName {
+		return historyTableName
+	}
+	return mig.tableName() + historyTableSuffix
+}
+
+func (mig *Mig) historyTable() string {
+	return mig.table(mig.historyName())
+}
+
+// History returns the operations recorded in the history table for the
+// configured namespace, oldest first
+func (mig *Mig) History() ([]HistoryEntry, error) {
+	rows, err := mig.config.Db.Query(
+		fmt.Sprintf(`
+		SELECT
+			entry, operation, migration_id, filename, old_hash, new_hash, statements,
+			duration_ms, outcome, error_text, actor, recorded_at
+		FROM
+			%s
+		WHERE
+			namespace = $1
+		ORDER BY
+			entry`, mig.historyTable()),
+		mig.config.Namespace,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("mig: error getting history: %w", err)
+	}
+	defer rows.Close()
+
+	var result []HistoryEntry
+	for rows.Next() {
+		var (
+			e          HistoryEntry
+			durationMs int64
+			recordedAt string
+		)
+		err = rows.Scan(
+			&e.Entry,
+			&e.Operation,
+			&e.Id,
+			&e.FileName,
+			&e.OldHash,
+			&e.NewHash,
+			&e.SQL,
+			&durationMs,
+			&e.Outcome,
+			&e.Error,
+			&e.Actor,
+			&recordedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("mig: error getting history: %w", err)
+		}
+
+		e.Duration = time.Duration(durationMs) * time.Millisecond
+		e.RecordedAt, err = time.Parse(time.RFC3339, recordedAt)
+		if err != nil {
+			return nil, fmt.Errorf("mig: invalid recorded_at %q in history entry %d: %w", recordedAt, e.Entry, err)
+		}
+		result = append(result, e)
+	}
+
+	return result, rows.Err()
+}
+
+// succeeded completes e for an operation started at start that succeeded
+func (e HistoryEntry) succeeded(start time.Time) HistoryEntry {
+	e.Duration = time.Since(start)
+	e.Outcome = OUTCOME_SUCCESS
+	return e
+}
+
+// failed completes e for an operation started at start that failed with err
+func (e HistoryEntry) failed(start time.Time, err error) HistoryEntry {
+	e.Duration = time.Since(start)
+	e.Outcome = OUTCOME_FAILURE
+	e.Error = err.Error()
+	return e
+}
+
+// recordHistory appends e to the history table as done now by the current
+// actor. Successful operations are recorded in the transaction they ran in.
+func (mig *Mig) recordHistory(ctx context.Context, db execer, e HistoryEntry) error {
+	_, err := db.ExecContext(
+		ctx,
+		fmt.Sprintf(`
+		INSERT INTO
+			%[1]s (namespace, entry, operation, migration_id, filename, old_hash, new_hash, statements, duration_ms, outcome, error_text, actor, recorded_at)
+		SELECT
+			$1, COALESCE(MAX(entry), 0) + 1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12
+		FROM
+			%[1]s`, mig.historyTable()),
+		mig.config.Namespace,
+		e.Operation,
+		e.Id,
+		e.FileName,
+		e.OldHash,
+		e.NewHash,
+		e.SQL,
+		e.Duration.Milliseconds(),
+		e.Outcome,
+		e.Error,
+		mig.actor(),
+		time.Now().UTC().Format(time.RFC3339),
+	)
+	if err != nil {
+		return fmt.Errorf("error recording %s of %s in history: %w", e.Operation, e.name(), err)
+	}
+	return nil
+}
+
+// recordFailure appends e to the history as failed with err once its
+// transaction was rolled back, and returns err
+func (mig *Mig) recordFailure(ctx context.Context, e HistoryEntry, start time.Time, err error) error {
+	// the failure may be the canceled context itself
+	herr := mig.recordHistory(context.WithoutCancel(ctx), mig.config.Db, e.failed(start, err))
+	if herr != nil {
+		return errors.Join(err, herr)
+	}
+	return err
+}
+
+// executed returns what running sql for m executes, the stored raw text of
+// a Go migration since it runs code instead
+func (m Migration) executed(sql string) string {
+	if isGoRaw(m.raw) {
+		return m.raw
+	}
+	return sql
+}
+
+// name identifies the migration of e in errors
+func (e HistoryEntry) name() string {
+	if e.Operation == HISTORY_REPEATABLE {
+		return e.FileName
+	}
+	return fmt.Sprintf("migration %d", e.Id)
+}
@@ -0,0 +1,89 @@
+package mig
+
+import (
+	"database/sql"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHistory(t *testing.T) {
+	t.Run("every up, down and repair is recorded, failures too", func(t *testing.T) {
+		testDbPath := "./test/test_history1.db"
+		db, err := sql.Open("sqlite3", testDbPath)
+		assert.Nil(t, err)
+		defer db.Close()
+
+		migrations := []Migration{
+			{Id: 1, Up: "CREATE TABLE test1 (id INTEGER PRIMARY KEY);", Down: "DROP TABLE test1;"},
+			{Id: 2, Up: "CREATE TABLE test2 (id INTEGER PRIMARY KEY);", Down: "DROP TABLE test2;"},
+			{FileName: "R__view.sql", Up: "CREATE VIEW IF NOT EXISTS view1 AS SELECT id FROM test1;", Repeatable: true},
+		}
+		m, err := New(Config{Db: db, Migrations: migrations})
+		assert.Nil(t, err)
+		err = m.Migrate()
+		assert.Nil(t, err)
+		firstHash := m.config.Migrations[1].hash
+
+		// a changed migration is rolled back and applied again
+		migrations[1].Up = "CREATE TABLE test2 (id INTEGER PRIMARY KEY, name TEXT);"
+		migrations = append(migrations, Migration{Id: 3, Up: "CREATE TABLE test2 (id INTEGER PRIMARY KEY);", Down: "DROP TABLE test2;"})
+		m, err = New(Config{Db: db, Migrations: migrations})
+		assert.Nil(t, err)
+		secondHash := m.config.Migrations[1].hash
+		err = m.Migrate()
+		assert.ErrorContains(t, err, "table test2 already exists")
+
+		migrations[0].Up = "CREATE TABLE test1 (id INTEGER PRIMARY KEY); -- edited"
+		m, err = New(Config{Db: db, Migrations: migrations[:3]})
+		assert.Nil(t, err)
+		_, err = m.Repair(1)
+		assert.Nil(t, err)
+
+		history, err := m.History()
+		assert.Nil(t, err)
+		tableMustExistSqlite(t, db, "mig_history")
+
+		type step struct {
+			operation string
+			id        int64
+			oldHash   string
+			newHash   string
+			outcome   string
+		}
+		var steps []step
+		for _, e := range history {
+			steps = append(steps, step{e.Operation, e.Id, e.OldHash, e.NewHash, e.Outcome})
+		}
+		assert.Equal(t, []step{
+			{HISTORY_UP, 1, "", history[0].NewHash, OUTCOME_SUCCESS},
+			{HISTORY_UP, 2, "", firstHash, OUTCOME_SUCCESS},
+			{HISTORY_REPEATABLE, 0, "", history[2].NewHash, OUTCOME_SUCCESS},
+			{HISTORY_DOWN, 2, firstHash, "", OUTCOME_SUCCESS},
+			{HISTORY_UP, 2, "", secondHash, OUTCOME_SUCCESS},
+			{HISTORY_UP, 3, "", history[5].NewHash, OUTCOME_FAILURE},
+			{HISTORY_REPAIR, 1, history[0].NewHash, m.config.Migrations[0].hash, OUTCOME_SUCCESS},
+		}, steps)
+
+		assert.Equal(t, "DROP TABLE test2;", history[3].SQL)
+		assert.Equal(t, "R__view.sql", history[2].FileName)
+		assert.Contains(t, history[5].Error, "table test2 already exists")
+		assert.Empty(t, history[4].Error)
+		for i, e := range history {
+			assert.Equal(t, int64(i+1), e.Entry)
+			assert.Contains(t, e.Actor, "@")
+			assert.False(t, e.RecordedAt.IsZero())
+		}
+
+		// the history of another namespace is kept apart
+		lib, err := New(Config{Db: db, Namespace: "lib"})
+		assert.Nil(t, err)
+		history, err = lib.History()
+		assert.Nil(t, err)
+		assert.Empty(t, history)
+
+		err = os.Remove(testDbPath)
+		assert.Nil(t, err)
+	})
+}
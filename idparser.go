@@ -0,0 +1,123 @@
+package mig
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// IDParser extracts the id and a description from the base name of a
+// migration file or folder, e.g. 1 and "create users" from
+// 0001_create_users.sql
+type IDParser func(fileName string) (id int64, description string, err error)
+
+// dottedVersionPartLimit bounds each part of a dotted version, which are
+// packed into the id in base 10000
+const (
+	dottedVersionPartLimit = 10000
+	dottedVersionMaxParts  = 4
+)
+
+// LeadingIntIDParser parses names starting with the id, like
+// 0001_create_users.sql or "03 - create users.sql". It is the default.
+func LeadingIntIDParser(fileName string) (int64, string, error) {
+	id, err := getIntFromFileName(fileName)
+	if err != nil {
+		return 0, "", err
+	}
+
+	return id, describe(strings.TrimLeft(fileName, "0123456789")), nil
+}
+
+// TimestampIDParser parses names starting with a UTC timestamp id, like
+// 20261017093000_create_users.sql, see ParseTimestampID
+func TimestampIDParser(fileName string) (int64, string, error) {
+	id, description, err := LeadingIntIDParser(fileName)
+	if err != nil {
+		return 0, "", err
+	}
+
+	_, err = ParseTimestampID(id)
+	if err != nil {
+		return 0, "", err
+	}
+	return id, description, nil
+}
+
+// FlywayIDParser parses Flyway style names like V12__create_users.sql
+func FlywayIDParser(fileName string) (int64, string, error) {
+	version, description, err := splitFlywayName(fileName)
+	if err != nil {
+		return 0, "", err
+	}
+
+	id, err := getIntFromFileName(version)
+	if err != nil {
+		return 0, "", err
+	}
+	if strconv.FormatInt(id, 10) != strings.TrimLeft(version, "0") {
+		return 0, "", fmt.Errorf("mig: version %q in %s is not an integer", version, fileName)
+	}
+
+	return id, description, nil
+}
+
+// DottedVersionIDParser parses Flyway style names with dotted versions like
+// V1.2.3__add_index.sql. Versions have at most 4 parts below 10000 each,
+// which are packed into the id so that ids order like the versions do,
+// e.g. 1.2.3 becomes 1000200030000.
+func DottedVersionIDParser(fileName string) (int64, string, error) {
+	version, description, err := splitFlywayName(fileName)
+	if err != nil {
+		return 0, "", err
+	}
+
+	parts := strings.Split(version, ".")
+	if len(parts) > dottedVersionMaxParts {
+		return 0, "", fmt.Errorf("mig: version %s in %s has more than %d parts", version, fileName, dottedVersionMaxParts)
+	}
+
+	var id int64
+	for i := 0; i < dottedVersionMaxParts; i++ {
+		id *= dottedVersionPartLimit
+		if i >= len(parts) {
+			continue
+		}
+
+		part, err := strconv.ParseInt(parts[i], 10, 64)
+		if err != nil || part < 0 || part >= dottedVersionPartLimit || strings.ContainsAny(parts[i], "+-") {
+			return 0, "", fmt.Errorf("mig: invalid version %s in %s", version, fileName)
+		}
+		id += part
+	}
+	if id < 1 {
+		return 0, "", fmt.Errorf("mig: version in filename must be greater than 0")
+	}
+
+	return id, description, nil
+}
+
+// splitFlywayName splits V<version>__<description>.sql into its version and description
+func splitFlywayName(fileName string) (version, description string, err error) {
+	rest, ok := strings.CutPrefix(fileName, "V")
+	if !ok {
+		return "", "", fmt.Errorf("mig: filename %s does not start with V", fileName)
+	}
+
+	version, description, ok = strings.Cut(rest, "__")
+	if !ok || version == "" {
+		return "", "", fmt.Errorf("mig: filename %s is not like V<version>__<description>", fileName)
+	}
+
+	// the description ends at the extension, the version may contain dots
+	return version, describe(description), nil
+}
+
+// describe turns the part of a file name after the id into a description,
+// e.g. "_create_users.up.sql" into "create users"
+func describe(rest string) string {
+	rest, _, _ = strings.Cut(rest, ".")
+	rest = strings.TrimLeft(rest, " _-:")
+	rest = strings.ReplaceAll(rest, "_", " ")
+	return strings.Join(strings.Fields(rest), " ")
+}
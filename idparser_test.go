@@ -0,0 +1,109 @@
+package mig
+
+import (
+	"database/sql"
+	"os"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIDParsers(t *testing.T) {
+	type result struct {
+		id          int64
+		description string
+	}
+	cases := []struct {
+		name     string
+		parse    IDParser
+		fileName string
+		expected result
+	}{
+		{"leading int", LeadingIntIDParser, "0001_create_users.sql", result{1, "create users"}},
+		{"leading int with dash", LeadingIntIDParser, "03 - create_table_users.sql", result{3, "create table users"}},
+		{"leading int up file", LeadingIntIDParser, "0012_add_index.up.sql", result{12, "add index"}},
+		{"leading int folder", LeadingIntIDParser, "0042_orders", result{42, "orders"}},
+		{"leading int without description", LeadingIntIDParser, "7.sql", result{7, ""}},
+		{"flyway", FlywayIDParser, "V12__create_users.sql", result{12, "create users"}},
+		{"flyway leading zeros", FlywayIDParser, "V012__create_users.sql", result{12, "create users"}},
+		{"dotted", DottedVersionIDParser, "V1.2.3__add_index.sql", result{1000200030000, "add index"}},
+		{"dotted single part", DottedVersionIDParser, "V2__x.sql", result{2000000000000, "x"}},
+		{"timestamp", TimestampIDParser, "20261017093000_create_users.sql", result{20261017093000, "create users"}},
+	}
+	for _, c := range cases {
+		id, description, err := c.parse(c.fileName)
+		assert.Nil(t, err, c.name)
+		assert.Equal(t, c.expected, result{id, description}, c.name)
+	}
+
+	invalid := []struct {
+		name     string
+		parse    IDParser
+		fileName string
+	}{
+		{"leading int without id", LeadingIntIDParser, "create_users.sql"},
+		{"leading int with unicode digits", LeadingIntIDParser, "١_create_users.sql"},
+		{"flyway without prefix", FlywayIDParser, "12__create_users.sql"},
+		{"flyway without separator", FlywayIDParser, "V12_create_users.sql"},
+		{"flyway with dotted version", FlywayIDParser, "V1.2__create_users.sql"},
+		{"flyway zero", FlywayIDParser, "V0__create_users.sql"},
+		{"dotted too many parts", DottedVersionIDParser, "V1.2.3.4.5__x.sql"},
+		{"dotted part too large", DottedVersionIDParser, "V1.10000__x.sql"},
+		{"dotted negative part", DottedVersionIDParser, "V1.-2__x.sql"},
+		{"dotted zero", DottedVersionIDParser, "V0.0__x.sql"},
+		{"timestamp too short", TimestampIDParser, "0001_create_users.sql"},
+	}
+	for _, c := range invalid {
+		_, _, err := c.parse(c.fileName)
+		assert.NotNil(t, err, c.name)
+	}
+
+	t.Run("dotted versions order like ids", func(t *testing.T) {
+		versions := []string{"V1__a.sql", "V1.0.1__a.sql", "V1.2__a.sql", "V1.10__a.sql", "V2__a.sql"}
+		var last int64
+		for _, v := range versions {
+			id, _, err := DottedVersionIDParser(v)
+			assert.Nil(t, err)
+			assert.Greater(t, id, last, v)
+			last = id
+		}
+	})
+}
+
+func TestIDParserConfig(t *testing.T) {
+	testDbPath := "./test/test_idparser1.db"
+	db, err := sql.Open("sqlite3", testDbPath)
+	assert.Nil(t, err)
+	defer db.Close()
+
+	fsys := fstest.MapFS{
+		"V1__create_users.sql":  {Data: []byte("-- up\nCREATE TABLE flyway_1 (id INTEGER PRIMARY KEY);\n-- down\nDROP TABLE flyway_1;")},
+		"V2__create_orders.sql": {Data: []byte("-- up\nCREATE TABLE flyway_2 (id INTEGER PRIMARY KEY);\n-- down\nDROP TABLE flyway_2;")},
+	}
+
+	_, err = New(Config{Db: db, Fs: fsys})
+	assert.NotNil(t, err, "default parser needs leading ids")
+
+	m, err := New(Config{
+		Db:       db,
+		Fs:       fsys,
+		IDParser: FlywayIDParser,
+	})
+	assert.Nil(t, err)
+
+	err = m.Migrate()
+	assert.Nil(t, err)
+	tableMustExistSqlite(t, db, "flyway_2")
+
+	var description string
+	err = db.QueryRow("SELECT description FROM migrations WHERE id = 2").Scan(&description)
+	assert.Nil(t, err)
+	assert.Equal(t, "create orders", description)
+
+	status, err := m.Status()
+	assert.Nil(t, err)
+	assert.Equal(t, "create users", status[0].Description)
+
+	os.Remove(testDbPath)
+}
@@ -0,0 +1,229 @@
+package mig
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// LAYOUT_VERSION is the version of the tracking tables' own layout this mig
+// writes. Tracking tables with an older layout are upgraded by New, a newer
+// layout is rejected.
+const LAYOUT_VERSION = 2
+
+const (
+	layoutTableSuffix  = "_layout"
+	layoutTableName    = migrationTableName + layoutTableSuffix
+	upgradeTableSuffix = "_upgrade"
+)
+
+var layoutTableSchema = `
+		CREATE TABLE IF NOT EXISTS %s (
+			version BIGINT NOT NULL
+		)
+`
+
+// layoutUpgrades moves the tracking tables from layout version i+1 to i+2.
+// Upgrades run in one transaction under the layout lock. A step that creates
+// a table creates it in the layout of its version, so changing the schema of
+// a table needs a copy of the old schema for the steps before.
+var layoutUpgrades = []func(mig *Mig, ctx context.Context, tx *sql.Tx) error{
+	(*Mig).upgradeBaseline,
+}
+
+func (mig *Mig) layoutTable() string {
+	return mig.table(mig.tableName() + layoutTableSuffix)
+}
+
+// upgradeLayout creates the tracking tables or upgrades them to LAYOUT_VERSION.
+// Concurrent callers wait for the lock on the layout table, so only one runs
+// the upgrades.
+func (mig *Mig) upgradeLayout(ctx context.Context) error {
+	_, err := mig.config.Db.ExecContext(ctx, fmt.Sprintf(layoutTableSchema, mig.layoutTable()))
+	if err != nil {
+		return fmt.Errorf("mig: error creating %s table: %w", mig.tableName()+layoutTableSuffix, err)
+	}
+
+	return mig.inTx(ctx, func(tx *sql.Tx) error {
+		_, err := tx.ExecContext(ctx, mig.dialect.lockTable(mig.layoutTable()))
+		if err != nil {
+			return fmt.Errorf("mig: error locking %s table: %w", mig.tableName()+layoutTableSuffix, err)
+		}
+
+		var version sql.NullInt64
+		err = tx.QueryRowContext(ctx, fmt.Sprintf("SELECT MAX(version) FROM %s", mig.layoutTable())).Scan(&version)
+		if err != nil {
+			return err
+		}
+
+		if !version.Valid {
+			exists, err := mig.tableExists(ctx, tx, mig.tableName())
+			if err != nil {
+				return err
+			}
+			if exists {
+				// created by the first versions of mig, before the layout was versioned
+				version.Int64 = 1
+			} else {
+				err = mig.createTables(ctx, tx)
+				if err != nil {
+					return fmt.Errorf("mig: error creating %s table: %w", mig.tableName(), err)
+				}
+				version.Int64 = LAYOUT_VERSION
+			}
+		}
+
+		if version.Int64 > LAYOUT_VERSION {
+			return fmt.Errorf(
+				"mig: the %s table has layout version %d, but this version of mig only knows up to %d, upgrade mig",
+				mig.tableName(), version.Int64, LAYOUT_VERSION,
+			)
+		}
+		if version.Valid && version.Int64 == LAYOUT_VERSION {
+			return nil
+		}
+
+		for v := version.Int64; v < LAYOUT_VERSION; v++ {
+			err = layoutUpgrades[v-1](mig, ctx, tx)
+			if err != nil {
+				return fmt.Errorf("mig: error upgrading %s table to layout version %d: %w", mig.tableName(), v+1, err)
+			}
+		}
+
+		_, err = tx.ExecContext(ctx, fmt.Sprintf("DELETE FROM %s", mig.layoutTable()))
+		if err != nil {
+			return err
+		}
+		_, err = tx.ExecContext(ctx, fmt.Sprintf("INSERT INTO %s (version) VALUES ($1)", mig.layoutTable()), LAYOUT_VERSION)
+		return err
+	})
+}
+
+// createTables creates the tracking tables in the layout of LAYOUT_VERSION
+func (mig *Mig) createTables(ctx context.Context, tx *sql.Tx) error {
+	for _, create := range []string{
+		fmt.Sprintf(migrationTableSchema, mig.migrationTable()),
+		fmt.Sprintf(repeatableTableSchema, mig.repeatableTable()),
+		fmt.Sprintf(repairTableSchema, mig.repairTable()),
+		fmt.Sprintf(historyTableSchema, mig.historyTable()),
+	} {
+		_, err := tx.ExecContext(ctx, create)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// upgradeBaseline upgrades the migrations table of the first versions of mig,
+// which had only the id, filename, raw, hash, up and down columns, with ids
+// from SERIAL on postgres. Rebuilding it gives the id its 64-bit type and the
+// primary key its namespace, rows that already exist are kept in the order of
+// their ids. The other tracking tables did not exist yet.
+func (mig *Mig) upgradeBaseline(ctx context.Context, tx *sql.Tx) error {
+	err := mig.rebuildTable(ctx, tx, mig.tableName(), migrationTableSchema, map[string]string{
+		"applied_order":  "id",
+		"description":    "''",
+		"author":         "''",
+		"ticket":         "''",
+		"tags":           "''",
+		"applied_at":     "''",
+		"execution_ms":   "0",
+		"applied_by":     "''",
+		"mig_version":    "''",
+		"hash_algorithm": "'" + HASH_ALGORITHM + "'",
+		"namespace":      "''",
+		"requires":       "''",
+	})
+	if err != nil {
+		return err
+	}
+	return mig.createTables(ctx, tx)
+}
+
+// rebuildTable moves the rows of the table name into a new one created from
+// schema, which fills the columns the old table lacks from defaults or with
+// NULL. This changes the primary key, which most databases cannot alter in
+// place.
+func (mig *Mig) rebuildTable(ctx context.Context, tx *sql.Tx, name, schema string, defaults map[string]string) error {
+	table := mig.table(name)
+	upgrade := mig.table(name + upgradeTableSuffix)
+
+	_, err := tx.ExecContext(ctx, fmt.Sprintf(schema, upgrade))
+	if err != nil {
+		return err
+	}
+
+	columns, err := tableColumns(ctx, tx, upgrade)
+	if err != nil {
+		return err
+	}
+	existing, err := tableColumns(ctx, tx, table)
+	if err != nil {
+		return err
+	}
+
+	var names, values []string
+	for _, c := range columns {
+		names = append(names, mig.dialect.quoteIdent(c))
+		if slices.Contains(existing, c) {
+			values = append(values, mig.dialect.quoteIdent(c))
+		} else if v, ok := defaults[c]; ok {
+			values = append(values, v)
+		} else {
+			values = append(values, "NULL")
+		}
+	}
+
+	_, err = tx.ExecContext(ctx, fmt.Sprintf(
+		"INSERT INTO %s (%s) SELECT %s FROM %s",
+		upgrade, strings.Join(names, ", "), strings.Join(values, ", "), table,
+	))
+	if err != nil {
+		return err
+	}
+	_, err = tx.ExecContext(ctx, fmt.Sprintf("DROP TABLE %s", table))
+	if err != nil {
+		return err
+	}
+	_, err = tx.ExecContext(ctx, mig.dialect.renameTable(upgrade, name))
+	if err != nil || mig.dialect != dialectPostgres {
+		return err
+	}
+
+	// postgres keeps the name of the primary key index, which the next
+	// rebuild would create again
+	_, err = tx.ExecContext(ctx, fmt.Sprintf(
+		"ALTER INDEX IF EXISTS %s RENAME TO %s",
+		mig.table(name+upgradeTableSuffix+"_pkey"), mig.dialect.quoteIdent(name+"_pkey"),
+	))
+	return err
+}
+
+// tableExists reports whether the tracking table name exists
+func (mig *Mig) tableExists(ctx context.Context, tx *sql.Tx, name string) (bool, error) {
+	var count int
+	query, args := mig.dialect.tableExists(name, mig.config.Schema)
+	err := tx.QueryRowContext(ctx, query, args...).Scan(&count)
+	return count > 0, err
+}
+
+// tableColumns returns the lower case column names of table
+func tableColumns(ctx context.Context, tx *sql.Tx, table string) ([]string, error) {
+	rows, err := tx.QueryContext(ctx, fmt.Sprintf("SELECT * FROM %s WHERE 1 = 0", table))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	columns, err := rows.Columns()
+	if err != nil {
+		return nil, err
+	}
+	for i := range columns {
+		columns[i] = strings.ToLower(columns[i])
+	}
+	return columns, rows.Err()
+}
@@ -0,0 +1,118 @@
+package mig
+
+import (
+	"database/sql"
+	"fmt"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func layoutVersion(t *testing.T, db *sql.DB, table string) int64 {
+	var version int64
+	err := db.QueryRow(fmt.Sprintf("SELECT version FROM %s", table)).Scan(&version)
+	assert.Nil(t, err)
+	return version
+}
+
+func TestLayout(t *testing.T) {
+	t.Run("tracking tables of older versions of mig are upgraded", func(t *testing.T) {
+		testDbPath := "./test/test_layout1.db"
+		db, err := sql.Open("sqlite3", testDbPath)
+		assert.Nil(t, err)
+		defer db.Close()
+
+		migrations := []Migration{
+			{Id: 1, Up: "CREATE TABLE test1 (id INTEGER PRIMARY KEY);", Down: "DROP TABLE test1;"},
+			{Id: 2, Up: "CREATE TABLE test2 (id INTEGER PRIMARY KEY);", Down: "DROP TABLE test2;"},
+		}
+
+		// the tracking table as the first versions of mig created and filled it
+		raw := getRaw(migrations[0].Up, migrations[0].Down, DEFAULT_UP_DELIMITER, DEFAULT_DOWN_DELIMITER)
+		_, err = db.Exec(`
+			CREATE TABLE migrations (id SERIAL PRIMARY KEY, filename TEXT, raw TEXT, hash TEXT, up TEXT, down TEXT);
+			CREATE TABLE test1 (id INTEGER PRIMARY KEY);
+		`)
+		assert.Nil(t, err)
+		_, err = db.Exec(
+			"INSERT INTO migrations (id, filename, raw, hash, up, down) VALUES (1, '', $1, $2, $3, $4)",
+			raw, hashRaw(raw), migrations[0].Up, migrations[0].Down,
+		)
+		assert.Nil(t, err)
+
+		m, err := New(Config{Db: db, Migrations: migrations})
+		assert.Nil(t, err)
+		assert.Equal(t, int64(LAYOUT_VERSION), layoutVersion(t, db, layoutTableName))
+		tableMustExistSqlite(t, db, repeatableTableName)
+		tableMustExistSqlite(t, db, historyTableName)
+		tableMustExistSqlite(t, db, repairTableName)
+		tableMustNotExistSqlite(t, db, migrationTableName+upgradeTableSuffix)
+
+		status, err := m.Status()
+		assert.Nil(t, err)
+		assert.True(t, status[0].Applied)
+		assert.False(t, status[0].Changed)
+		assert.Equal(t, int64(1), status[0].AppliedOrder)
+		assert.Equal(t, HASH_ALGORITHM, status[0].Execution.HashAlgorithm)
+		assert.False(t, status[1].Applied)
+
+		err = m.Migrate()
+		assert.Nil(t, err)
+		tableMustExistSqlite(t, db, "test2")
+
+		// the primary key now includes the namespace
+		lib, err := New(Config{Db: db, Namespace: "lib", Migrations: []Migration{
+			{Id: 1, Up: "CREATE TABLE lib1 (id INTEGER PRIMARY KEY);", Down: "DROP TABLE lib1;"},
+		}})
+		assert.Nil(t, err)
+		err = lib.Migrate()
+		assert.Nil(t, err)
+		assert.Equal(t, 3, countRows(t, db, migrationTableName))
+
+		err = os.Remove(testDbPath)
+		assert.Nil(t, err)
+	})
+
+	t.Run("up to date tracking tables are left alone", func(t *testing.T) {
+		testDbPath := "./test/test_layout2.db"
+		db, err := sql.Open("sqlite3", testDbPath)
+		assert.Nil(t, err)
+		defer db.Close()
+
+		migrations := []Migration{{Id: 1, Up: "CREATE TABLE test1 (id INTEGER PRIMARY KEY);", Down: "DROP TABLE test1;"}}
+		m, err := New(Config{Db: db, Migrations: migrations})
+		assert.Nil(t, err)
+		err = m.Migrate()
+		assert.Nil(t, err)
+
+		_, err = New(Config{Db: db, Migrations: migrations})
+		assert.Nil(t, err)
+		assert.Equal(t, 1, countRows(t, db, layoutTableName))
+		assert.Equal(t, 1, countRows(t, db, migrationTableName))
+
+		err = os.Remove(testDbPath)
+		assert.Nil(t, err)
+	})
+
+	t.Run("a newer layout is rejected", func(t *testing.T) {
+		testDbPath := "./test/test_layout3.db"
+		db, err := sql.Open("sqlite3", testDbPath)
+		assert.Nil(t, err)
+		defer db.Close()
+
+		_, err = New(Config{Db: db})
+		assert.Nil(t, err)
+		_, err = db.Exec(fmt.Sprintf("UPDATE %s SET version = %d", layoutTableName, LAYOUT_VERSION+1))
+		assert.Nil(t, err)
+
+		_, err = New(Config{Db: db})
+		assert.EqualError(t, err, fmt.Sprintf(
+			"mig: the migrations table has layout version %d, but this version of mig only knows up to %d, upgrade mig",
+			LAYOUT_VERSION+1, LAYOUT_VERSION,
+		))
+
+		err = os.Remove(testDbPath)
+		assert.Nil(t, err)
+	})
+}
@@ -0,0 +1,64 @@
+package mig
+
+import (
+	"strings"
+)
+
+// Directives of the optional header of a migration file, e.g.
+//
+//	-- mig:description add the orders table
+//	-- mig:author jane
+//	-- mig:ticket SHOP-123
+//	-- mig:tags billing, orders
+const (
+	DESCRIPTION_DIRECTIVE = "description"
+	AUTHOR_DIRECTIVE      = "author"
+	TICKET_DIRECTIVE      = "ticket"
+	TAGS_DIRECTIVE        = "tags"
+)
+
+// Metadata describes who wrote a migration and why. It is stored with
+// applied migrations but not hashed, so editing it never re-runs one.
+type Metadata struct {
+	Author string
+	Ticket string
+	Tags   []string
+}
+
+// header returns the directives for the description, metadata and
+// requirements of m, one per line
+func (m Migration) header() string {
+	var b strings.Builder
+	if m.Description != "" {
+		b.WriteString(DIRECTIVE_PREFIX + DESCRIPTION_DIRECTIVE + " " + m.Description + "\n")
+	}
+	if m.Metadata.Author != "" {
+		b.WriteString(DIRECTIVE_PREFIX + AUTHOR_DIRECTIVE + " " + m.Metadata.Author + "\n")
+	}
+	if m.Metadata.Ticket != "" {
+		b.WriteString(DIRECTIVE_PREFIX + TICKET_DIRECTIVE + " " + m.Metadata.Ticket + "\n")
+	}
+	if len(m.Metadata.Tags) > 0 {
+		b.WriteString(DIRECTIVE_PREFIX + TAGS_DIRECTIVE + " " + joinList(m.Metadata.Tags) + "\n")
+	}
+	if len(m.Requires) > 0 {
+		b.WriteString(DIRECTIVE_PREFIX + REQUIRES_DIRECTIVE + " " + joinList(m.Requires) + "\n")
+	}
+	return b.String()
+}
+
+// splitList splits a list, like tags, separated by commas or whitespace
+func splitList(s string) []string {
+	tags := strings.FieldsFunc(s, func(r rune) bool {
+		return r == ',' || r == ' ' || r == '\t'
+	})
+	if len(tags) == 0 {
+		return nil
+	}
+	return tags
+}
+
+// joinList is the inverse of splitList, it is also how tags are stored
+func joinList(items []string) string {
+	return strings.Join(items, ", ")
+}
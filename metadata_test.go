@@ -0,0 +1,102 @@
+package mig
+
+import (
+	"database/sql"
+	"os"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMetadata(t *testing.T) {
+	t.Run("header directives are parsed, stored and exported", func(t *testing.T) {
+		testDbPath := "./test/test_metadata1.db"
+		db, err := sql.Open("sqlite3", testDbPath)
+		assert.Nil(t, err)
+		defer db.Close()
+
+		fsys := fstest.MapFS{
+			"0001_orders.sql": {Data: []byte(`-- mig:description add the orders table
+-- mig:author jane
+-- mig:ticket SHOP-123
+-- mig:tags billing, orders
+-- up
+CREATE TABLE orders (id INTEGER PRIMARY KEY);
+-- down
+DROP TABLE orders;
+`)},
+		}
+
+		m, err := New(Config{Db: db, Fs: fsys})
+		assert.Nil(t, err)
+
+		expected := Metadata{Author: "jane", Ticket: "SHOP-123", Tags: []string{"billing", "orders"}}
+		assert.Equal(t, "add the orders table", m.config.Migrations[0].Description)
+		assert.Equal(t, expected, m.config.Migrations[0].Metadata)
+
+		err = m.Migrate()
+		assert.Nil(t, err)
+
+		dbMigrations, err := m.getMigrationsFromDB()
+		assert.Nil(t, err)
+		assert.Equal(t, expected, dbMigrations[0].Metadata)
+		assert.Equal(t, "add the orders table", dbMigrations[0].Description)
+
+		status, err := m.Status()
+		assert.Nil(t, err)
+		assert.Equal(t, expected, status[0].Metadata)
+
+		dir := t.TempDir()
+		err = m.ExportApplied(dir)
+		assert.Nil(t, err)
+		contents, err := os.ReadFile(filepath.Join(dir, "0001_orders.sql"))
+		assert.Nil(t, err)
+		assert.Contains(t, string(contents), "-- mig:description add the orders table\n-- mig:author jane\n-- mig:ticket SHOP-123\n-- mig:tags billing, orders\n-- up\n")
+
+		// the export reads back with the same description
+		exported, err := NewFSSource(os.DirFS(dir), "").Read(1)
+		assert.Nil(t, err)
+		assert.Equal(t, "add the orders table", exported.Description)
+
+		// metadata is not hashed, editing it does not re-run the migration
+		fsys["0001_orders.sql"] = &fstest.MapFile{Data: []byte("-- mig:author joe\n-- up\nCREATE TABLE orders (id INTEGER PRIMARY KEY);\n-- down\nDROP TABLE orders;\n")}
+		m, err = New(Config{Db: db, Fs: fsys})
+		assert.Nil(t, err)
+		status, err = m.Status()
+		assert.Nil(t, err)
+		assert.False(t, status[0].Changed)
+
+		err = os.Remove(testDbPath)
+		assert.Nil(t, err)
+	})
+
+	t.Run("folders take metadata from meta.yaml", func(t *testing.T) {
+		source := NewFSSource(fstest.MapFS{
+			"0001_orders/up.sql":    {Data: []byte("CREATE TABLE orders (id INTEGER PRIMARY KEY);")},
+			"0001_orders/meta.yaml": {Data: []byte("author: jane\nticket: SHOP-123\ntags: [billing]\n")},
+		}, "")
+
+		m, err := source.Read(1)
+		assert.Nil(t, err)
+		assert.Equal(t, "orders", m.Description)
+		assert.Equal(t, Metadata{Author: "jane", Ticket: "SHOP-123", Tags: []string{"billing"}}, m.Metadata)
+	})
+
+	t.Run("invalid directives are reported by line", func(t *testing.T) {
+		format := MigFormat("", "")
+
+		_, err := format("-- mig:author jane\n-- mig:author joe\n-- up\n-- down\n")
+		assert.EqualError(t, err, "line 2: duplicate directive -- mig:author, first at line 1")
+
+		_, err = format("-- mig:ticket\n-- up\n-- down\n")
+		assert.EqualError(t, err, "line 1: directive -- mig:ticket needs a value")
+	})
+}
+
+func TestSplitList(t *testing.T) {
+	assert.Equal(t, []string{"a", "b", "c"}, splitList("a, b c,,"))
+	assert.Nil(t, splitList(" , "))
+	assert.Equal(t, "a, b", joinList(splitList("a,b")))
+}
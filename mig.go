@@ -1,10 +1,13 @@
 package mig
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
 	"io/fs"
+	"log/slog"
 	"sort"
+	"time"
 )
 
 const (
@@ -21,39 +24,178 @@ type Config struct {
 	Fs              fs.FS
 	OverrideDirName string
 
+	// Recursive includes migrations in subdirectories of the Fs directory,
+	// their FileName is the path relative to it
+	Recursive bool
+
+	// Extensions and Ignore select the files in Fs that are migrations,
+	// see FSSource
+	Extensions []string
+	Ignore     []string
+
+	// Logger receives debug messages, e.g. about files skipped in Fs
+	Logger *slog.Logger
+
+	// IDParser extracts ids and descriptions from the file names in Fs,
+	// LeadingIntIDParser if nil. FlywayIDParser, DottedVersionIDParser and
+	// TimestampIDParser are alternatives.
+	IDParser IDParser
+
 	// If Fs is nil, then this slice of migrations will be used
 	Migrations []Migration
 
+	// TableName is the table applied migrations are recorded in, migrations
+	// if empty. Repeatable migrations are recorded in the same name with a
+	// _repeatable suffix. Repairs and the history are recorded in
+	// migration_repairs and mig_history, or with a _repairs and _history
+	// suffix if TableName is set.
+	TableName string
+
+	// Schema holds the tracking tables on Postgres and SQL Server, the
+	// default schema of the connection if empty
+	Schema string
+
+	// ApplicationName is recorded after user@host as who applied a
+	// migration, e.g. the name of the service running Migrate
+	ApplicationName string
+
+	// Namespace separates the migrations of several Mig instances sharing a
+	// database, e.g. a library's and the application's. Each only sees and
+	// rolls back the applied migrations of its own namespace.
+	Namespace string
+
+	// Source takes precedence over Fs and Migrations if set, use MultiSource
+	// to combine migrations from several places
+	Source Source
+
 	// Delimiters for splitting up and down migrations in a single file
 	UpDelimiter   string
 	DownDelimiter string
+
+	// Format parses the files in Fs, MigFormat with the delimiters if nil.
+	// GooseFormat and SQLMigrateFormat read files written for those tools.
+	Format Format
+
+	// TimestampIDs requires every id to be a UTC timestamp like
+	// 20261017093000, see GenerateTimestampID
+	TimestampIDs bool
+
+	// AllowOutOfOrder applies migrations with an id lower than the last
+	// applied one, e.g. after merging a branch, instead of skipping them.
+	// Rollbacks then follow the order migrations were applied in.
+	AllowOutOfOrder bool
+
+	// RejectGaps makes New fail if the ids are not contiguous, e.g. 1, 2, 4.
+	// It cannot be combined with TimestampIDs.
+	RejectGaps bool
 }
 
 // Mig is the main struct for the mig package
 type Mig struct {
-	config Config
+	config  Config
+	dialect dialect
+
+	// repeatables are applied after all versioned migrations whenever they change
+	repeatables []Migration
 }
 
+const migrationTableName = "migrations"
+
+// migrationTableSchema is the layout of LAYOUT_VERSION, changing it needs an
+// upgrade in layoutUpgrades for existing tables
 var migrationTableSchema = `
-		CREATE TABLE IF NOT EXISTS migrations (
-			id SERIAL PRIMARY KEY,
+		CREATE TABLE IF NOT EXISTS %s (
+			id BIGINT,
 			filename TEXT,
 			raw TEXT,
 			hash TEXT,
 			up TEXT,
-			down TEXT
+			down TEXT,
+			applied_order BIGINT,
+			description TEXT,
+			author TEXT,
+			ticket TEXT,
+			tags TEXT,
+			applied_at TEXT,
+			execution_ms BIGINT,
+			applied_by TEXT,
+			mig_version TEXT,
+			hash_algorithm TEXT,
+			namespace TEXT NOT NULL DEFAULT '',
+			requires TEXT,
+			PRIMARY KEY (namespace, id)
 		)
 `
 
 type Migration struct {
-	Id       int
+	Id       int64
 	FileName string
 
+	// Description is a human readable summary of the migration
+	Description string
+
 	raw  string
 	hash string
 
+	// appliedOrder is the position in which an applied migration was applied
+	appliedOrder int64
+
+	// execution is recorded when a migration is applied
+	execution Execution
+
 	Up   string
 	Down string
+
+	// UpFunc and DownFunc make this a Go migration, they are run instead of
+	// Up and Down in the transaction that records the migration
+	UpFunc   MigrationFunc
+	DownFunc MigrationFunc
+
+	// Version is hashed instead of the SQL of a Go migration and is required
+	// for them. Change it when the functions change to apply them again.
+	Version string
+
+	// Requires lists the migrations that must be applied before this one, by
+	// id or directory and id like billing/0003. Migrations are applied in id
+	// order otherwise. Set it in files with -- mig:requires.
+	Requires []string
+
+	// Metadata is set in files with -- mig:author, -- mig:ticket and -- mig:tags
+	Metadata Metadata
+
+	// NoTransaction runs the SQL of the migration outside a transaction, e.g.
+	// for CREATE INDEX CONCURRENTLY. Other SQL migrations are applied and
+	// recorded in one transaction. Set it in files with -- mig:no-transaction.
+	NoTransaction bool
+
+	// SplitStatements runs each statement of Up and Down with its own query,
+	// for drivers that reject several statements in one query. Statements are
+	// split like goose does, see splitStatements. GooseFormat and
+	// SQLMigrateFormat set it, set it in other files with -- mig:split-statements.
+	SplitStatements bool
+
+	// Repeatable migrations have no id and no down part. They are applied
+	// after all versioned migrations, and again whenever Up changes. They
+	// are tracked by FileName, which must be unique among them.
+	Repeatable bool
+}
+
+// tableName returns the name of the tracking table, see Config.TableName
+func (mig *Mig) tableName() string {
+	return defaultString(mig.config.TableName, migrationTableName)
+}
+
+// table returns the quoted name of one of mig's own tables, qualified with
+// the configured schema
+func (mig *Mig) table(name string) string {
+	if mig.config.Schema == "" {
+		return mig.dialect.quoteIdent(name)
+	}
+	return mig.dialect.quoteIdent(mig.config.Schema) + "." + mig.dialect.quoteIdent(name)
+}
+
+func (mig *Mig) migrationTable() string {
+	return mig.table(mig.tableName())
 }
 
 func New(c Config) (*Mig, error) {
@@ -65,42 +207,99 @@ func New(c Config) (*Mig, error) {
 	}
 
 	m := &Mig{
-		config: c,
+		config:  c,
+		dialect: detectDialect(c.Db),
 	}
 
 	if m.config.Db == nil {
 		return &Mig{}, fmt.Errorf("db is nil")
 	}
 
-	// Create migrations table if it doesn't exist
-	_, err := m.config.Db.Exec(migrationTableSchema)
+	if m.config.Schema != "" && m.dialect != dialectPostgres && m.dialect != dialectMssql {
+		return &Mig{}, fmt.Errorf("mig: Schema is not supported on %s", m.dialect)
+	}
+
+	// Create the tracking tables or upgrade them to the current layout
+	err := m.upgradeLayout(context.Background())
 	if err != nil {
-		return &Mig{}, fmt.Errorf("mig: error creating migrations table: %w", err)
+		return &Mig{}, err
 	}
 
-	// Get migrations from filesystem or from the provided slice
-	if m.config.Fs != nil {
-		m.config.Migrations, err = m.getMigrationsFromFS()
+	// Get migrations from the source, the filesystem or the provided slice
+	if m.config.Source != nil {
+		m.config.Migrations, err = readSource(m.config.Source)
+		if err != nil {
+			return &Mig{}, fmt.Errorf("mig: error getting migrations from source: %w", err)
+		}
+		m.repeatables, err = readRepeatables(m.config.Source)
+		if err != nil {
+			return &Mig{}, fmt.Errorf("mig: error getting repeatable migrations from source: %w", err)
+		}
+	} else if m.config.Fs != nil {
+		m.config.Migrations, m.repeatables, err = m.getMigrationsFromFS()
 		if err != nil {
 			return &Mig{}, fmt.Errorf("mig: error getting migrations from fs: %w", err)
 		}
+	} else {
+		m.config.Migrations, m.repeatables = splitRepeatables(m.config.Migrations)
+	}
+
+	err = validateRepeatables(m.repeatables)
+	if err != nil {
+		return &Mig{}, err
 	}
 	sort.Slice(m.config.Migrations, func(i, j int) bool {
 		return m.config.Migrations[i].Id < m.config.Migrations[j].Id
 	})
 
+	if m.config.RejectGaps && m.config.TimestampIDs {
+		return &Mig{}, fmt.Errorf("mig: RejectGaps cannot be used with TimestampIDs")
+	}
+	err = validateSequence(m.config.Migrations, m.config.RejectGaps)
+	if err != nil {
+		return &Mig{}, err
+	}
+	m.config.Migrations, err = orderByDependencies(m.config.Migrations)
+	if err != nil {
+		return &Mig{}, err
+	}
+
+	for _, migration := range m.config.Migrations {
+		err = migration.validateGo()
+		if err != nil {
+			return &Mig{}, err
+		}
+
+		if m.config.TimestampIDs {
+			_, err = ParseTimestampID(migration.Id)
+			if err != nil {
+				return &Mig{}, fmt.Errorf("%w: %s", err, migration.FileName)
+			}
+		}
+	}
+
 	m.assignRawAndHashes()
 	return m, nil
 }
 
 func (mig *Mig) Migrate() error {
+	return mig.MigrateContext(context.Background())
+}
+
+// MigrateContext is like Migrate, ctx is passed on to queries and Go migrations
+func (mig *Mig) MigrateContext(ctx context.Context) error {
 	mig.assignRawAndHashes()
-	err := mig.runDown()
+	err := mig.runDown(ctx)
+	if err != nil {
+		return err
+	}
+
+	err = mig.runUp(ctx)
 	if err != nil {
 		return err
 	}
 
-	err = mig.runUp()
+	err = mig.runRepeatables(ctx)
 	if err != nil {
 		return err
 	}
@@ -110,64 +309,137 @@ func (mig *Mig) Migrate() error {
 
 func (mig *Mig) assignRawAndHashes() {
 	for i := range mig.config.Migrations {
-		mig.config.Migrations[i].raw = getRaw(
-			mig.config.Migrations[i].Up,
-			mig.config.Migrations[i].Down,
-			mig.config.UpDelimiter,
-			mig.config.DownDelimiter,
-		)
+		if mig.config.Migrations[i].isGo() {
+			mig.config.Migrations[i].raw = getGoRaw(mig.config.Migrations[i].Version)
+		} else {
+			mig.config.Migrations[i].raw = getSqlRaw(
+				mig.config.Migrations[i],
+				mig.config.UpDelimiter,
+				mig.config.DownDelimiter,
+			)
+		}
 		mig.config.Migrations[i].hash = hashRaw(mig.config.Migrations[i].raw)
 	}
+
+	for i := range mig.repeatables {
+		mig.repeatables[i].raw = mig.repeatables[i].Up
+		mig.repeatables[i].hash = hashRaw(mig.repeatables[i].raw)
+	}
 }
 
-func (mig *Mig) runUp() error {
+func (mig *Mig) runUp(ctx context.Context) error {
 	dbMigrations, err := mig.getMigrationsFromDB()
 	if err != nil {
 		return err
 	}
-	lastId := 0
-	if len(dbMigrations) > 0 {
-		lastId = dbMigrations[len(dbMigrations)-1].Id
+	order := mig.sourceOrder()
+	applied := make(map[int64]bool, len(dbMigrations))
+	for _, dbMig := range dbMigrations {
+		applied[dbMig.Id] = true
 	}
 
 	for _, m := range mig.config.Migrations {
-		if m.Id <= lastId {
+		if applied[m.Id] || (!mig.config.AllowOutOfOrder && order.appliedAfter(m.Id, dbMigrations)) {
 			continue
 		}
 
-		_, err := mig.config.Db.Exec(m.Up)
-		if err != nil {
-			return err
+		entry := HistoryEntry{Operation: HISTORY_UP, Id: m.Id, FileName: m.FileName, NewHash: m.hash, SQL: m.executed(m.Up)}
+		start := time.Now()
+		if m.NoTransaction {
+			err := execSQL(ctx, mig.config.Db, m, m.Up)
+			if err != nil {
+				return mig.recordFailure(ctx, entry, start, err)
+			}
+
+			m.execution = mig.execution(start)
+			err = mig.insertMigration(ctx, mig.config.Db, m)
+			if err != nil {
+				return mig.recordFailure(ctx, entry, start, err)
+			}
+			err = mig.recordHistory(ctx, mig.config.Db, entry.succeeded(start))
+			if err != nil {
+				return err
+			}
+			continue
 		}
 
-		_, err = mig.config.Db.Exec(
-			`
-		INSERT INTO 
-			migrations (id, filename, raw, hash, up, down) 
-		VALUES 
-			($1, $2, $3, $4, $5, $6)`,
-			m.Id,
-			m.FileName,
-			m.raw,
-			m.hash,
-			m.Up,
-			m.Down,
-		)
+		err = mig.inTx(ctx, func(tx *sql.Tx) error {
+			if m.isGo() {
+				err := m.UpFunc(ctx, tx)
+				if err != nil {
+					return fmt.Errorf("error running go migration %d: %w", m.Id, err)
+				}
+			} else {
+				err := execSQL(ctx, tx, m, m.Up)
+				if err != nil {
+					return err
+				}
+			}
+			m.execution = mig.execution(start)
+			err := mig.insertMigration(ctx, tx, m)
+			if err != nil {
+				return err
+			}
+			return mig.recordHistory(ctx, tx, entry.succeeded(start))
+		})
 		if err != nil {
-			return err
+			return mig.recordFailure(ctx, entry, start, err)
 		}
 	}
 
 	return nil
 }
 
+// insertMigration records an applied migration and its execution in the migrations table
+func (mig *Mig) insertMigration(ctx context.Context, db execer, m Migration) error {
+	_, err := db.ExecContext(
+		ctx,
+		fmt.Sprintf(`
+		INSERT INTO 
+			%[1]s (id, filename, raw, hash, up, down, applied_order, description, author, ticket, tags, applied_at, execution_ms, applied_by, mig_version, hash_algorithm, namespace, requires) 
+		SELECT 
+			$1, $2, $3, $4, $5, $6, COALESCE(MAX(applied_order), 0) + 1, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17
+		FROM
+			%[1]s
+		WHERE
+			namespace = $16`, mig.migrationTable()),
+		m.Id,
+		m.FileName,
+		m.raw,
+		m.hash,
+		m.Up,
+		m.Down,
+		m.Description,
+		m.Metadata.Author,
+		m.Metadata.Ticket,
+		joinList(m.Metadata.Tags),
+		m.execution.AppliedAt.Format(time.RFC3339),
+		m.execution.Duration.Milliseconds(),
+		m.execution.AppliedBy,
+		m.execution.MigVersion,
+		m.execution.HashAlgorithm,
+		mig.config.Namespace,
+		joinList(m.Requires),
+	)
+	return err
+}
+
 // runDown finds if there are down migrations that need to be run and runs all migrations down to them
-func (mig *Mig) runDown() error {
+func (mig *Mig) runDown(ctx context.Context) error {
 	dbMigrations, err := mig.getMigrationsFromDB()
 	if err != nil {
 		return err
 	}
 
+	order := mig.sourceOrder()
+	sort.SliceStable(dbMigrations, func(i, j int) bool {
+		return order.before(dbMigrations[i].Id, dbMigrations[j].Id)
+	})
+
+	if mig.config.AllowOutOfOrder {
+		return mig.runDownOutOfOrder(ctx, dbMigrations)
+	}
+
 	// find any hash mismatches, and run down to the first one
 	for i, dbMig := range dbMigrations {
 		if i >= len(mig.config.Migrations) {
@@ -175,132 +447,252 @@ func (mig *Mig) runDown() error {
 		}
 		if dbMig.Id != mig.config.Migrations[i].Id {
 			return fmt.Errorf(
-				"mismatched migration id: dbMig.Id=%d, mig.config.Migrations[i].Id=%d",
-				dbMig.Id,
-				mig.config.Migrations[i].Id,
+				"mismatched migration id: applied %s, source %s",
+				migrationName(dbMig),
+				migrationName(mig.config.Migrations[i]),
 			)
 		}
 		if dbMig.hash != mig.config.Migrations[i].hash {
-			return mig.runDownTo(dbMig.Id)
+			err = mig.runDownTo(ctx, dbMig.Id)
+			if err != nil {
+				return fmt.Errorf(
+					"error rolling back changed migration %d: %w\n%s",
+					dbMig.Id,
+					err,
+					migrationDiff(dbMig, mig.config.Migrations[i]),
+				)
+			}
+			return nil
 		}
 	}
 
 	// if there are more migrations in the db than in the slice, run down to the end of the slice
 	if len(dbMigrations) > len(mig.config.Migrations) {
-		lastId := mig.config.Migrations[len(mig.config.Migrations)-1].Id + 1
-		return mig.runDownTo(lastId)
+		return mig.runDownTo(ctx, dbMigrations[len(mig.config.Migrations)].Id)
 	}
 
 	return nil
 }
 
-func (mig *Mig) runDownTo(endId int) error {
+// runDownOutOfOrder is runDown for AllowOutOfOrder, where migrations are
+// matched by id since lower ids may not have been applied yet
+func (mig *Mig) runDownOutOfOrder(ctx context.Context, dbMigrations []Migration) error {
+	current := make(map[int64]Migration, len(mig.config.Migrations))
+	for _, m := range mig.config.Migrations {
+		current[m.Id] = m
+	}
+
+	// run down to the first migration that changed or is no longer in the source
+	for _, dbMig := range dbMigrations {
+		m, ok := current[dbMig.Id]
+		if !ok {
+			return mig.runDownTo(ctx, dbMig.Id)
+		}
+		if dbMig.hash != m.hash {
+			err := mig.runDownTo(ctx, dbMig.Id)
+			if err != nil {
+				return fmt.Errorf(
+					"error rolling back changed migration %d: %w\n%s",
+					dbMig.Id,
+					err,
+					migrationDiff(dbMig, m),
+				)
+			}
+			return nil
+		}
+	}
+
+	return nil
+}
+
+// runDownTo rolls back the applied migration endId and every applied
+// migration after it in the source order or applied after it, since those may
+// depend on it, in the reverse order they were applied in
+func (mig *Mig) runDownTo(ctx context.Context, endId int64) error {
 	dbMigrations, err := mig.getMigrationsFromDB()
 	if err != nil {
 		return fmt.Errorf("error getting migrations from db: %w", err)
 	}
 
+	sort.SliceStable(dbMigrations, func(i, j int) bool {
+		return dbMigrations[i].appliedOrder < dbMigrations[j].appliedOrder
+	})
+
+	var endOrder int64
+	for _, dbMig := range dbMigrations {
+		if dbMig.Id == endId {
+			endOrder = dbMig.appliedOrder
+		}
+	}
+
+	order := mig.sourceOrder()
 	for i := len(dbMigrations) - 1; i >= 0; i-- {
-		if dbMigrations[i].Id < endId {
-			break
+		if order.before(dbMigrations[i].Id, endId) && dbMigrations[i].appliedOrder < endOrder {
+			continue
 		}
 
-		// run down migration
-		_, err := mig.config.Db.Exec(dbMigrations[i].Down)
-		if err != nil {
-			return fmt.Errorf("error running down migration: %w", err)
+		dbMig := dbMigrations[i]
+		entry := HistoryEntry{Operation: HISTORY_DOWN, Id: dbMig.Id, FileName: dbMig.FileName, OldHash: dbMig.hash, SQL: dbMig.executed(dbMig.Down)}
+		start := time.Now()
+
+		if isGoRaw(dbMig.raw) {
+			m, ok := mig.findGoMigration(dbMig.Id)
+			if !ok {
+				return fmt.Errorf("error running down migration: go migration %d is no longer in the source", dbMig.Id)
+			}
+
+			err = mig.inTx(ctx, func(tx *sql.Tx) error {
+				if m.DownFunc != nil {
+					err := m.DownFunc(ctx, tx)
+					if err != nil {
+						return fmt.Errorf("error running down migration: %w", err)
+					}
+				}
+				err := mig.deleteMigration(ctx, tx, m.Id)
+				if err != nil {
+					return err
+				}
+				return mig.recordHistory(ctx, tx, entry.succeeded(start))
+			})
+			if err != nil {
+				return mig.recordFailure(ctx, entry, start, err)
+			}
+			continue
 		}
 
-		// remove migration from migrations table
-		_, err = mig.config.Db.Exec(
-			"DELETE FROM migrations WHERE id = $1",
-			dbMigrations[i].Id,
-		)
+		rollback := func(db execer) error {
+			// run down migration
+			err := execSQL(ctx, db, dbMig, dbMig.Down)
+			if err != nil {
+				return fmt.Errorf("error running down migration: %w", err)
+			}
+
+			// remove migration from migrations table
+			err = mig.deleteMigration(ctx, db, dbMig.Id)
+			if err != nil {
+				return err
+			}
+			return mig.recordHistory(ctx, db, entry.succeeded(start))
+		}
+
+		if dbMig.NoTransaction {
+			err = rollback(mig.config.Db)
+		} else {
+			err = mig.inTx(ctx, func(tx *sql.Tx) error {
+				return rollback(tx)
+			})
+		}
 		if err != nil {
-			return fmt.Errorf("error deleting migration from migrations table: %w", err)
+			return mig.recordFailure(ctx, entry, start, err)
 		}
 	}
 
 	return nil
 }
 
-func (mig *Mig) getMigrationsFromFS() ([]Migration, error) {
-	var (
-		result  []Migration
-		entries []fs.DirEntry
-		err     error
+// deleteMigration removes a rolled back migration from the migrations table
+func (mig *Mig) deleteMigration(ctx context.Context, db execer, id int64) error {
+	_, err := db.ExecContext(
+		ctx,
+		fmt.Sprintf("DELETE FROM %s WHERE namespace = $1 AND id = $2", mig.migrationTable()),
+		mig.config.Namespace,
+		id,
 	)
+	if err != nil {
+		return fmt.Errorf("error deleting migration from migrations table: %w", err)
+	}
+	return nil
+}
 
-	if mig.config.OverrideDirName == "" {
-		entries, err = fs.ReadDir(
-			mig.config.Fs,
-			".",
-		)
-		if err != nil {
-			return nil, err
-		}
-	} else {
-		entries, err = fs.ReadDir(
-			mig.config.Fs,
-			mig.config.OverrideDirName,
-		)
-		if err != nil {
-			return nil, err
-		}
+// inTx runs f in a transaction, which is committed if f succeeds
+func (mig *Mig) inTx(ctx context.Context, f func(tx *sql.Tx) error) error {
+	tx, err := mig.config.Db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
 	}
+	defer tx.Rollback()
 
-	for _, entry := range entries {
-		if entry.IsDir() {
-			continue
-		}
+	err = f(tx)
+	if err != nil {
+		return err
+	}
 
-		var (
-			m        = Migration{}
-			contents []byte
-			err      error
-		)
+	return tx.Commit()
+}
 
-		m.FileName = entry.Name()
-		m.Id, err = getIntFromFileName(m.FileName)
-		if err != nil {
-			return nil, err
-		}
+func (mig *Mig) getMigrationsFromFS() (migrations []Migration, repeatables []Migration, err error) {
+	source := mig.fsSource(mig.config.Fs, mig.config.OverrideDirName)
 
-		if mig.config.OverrideDirName != "" {
-			contents, err = fs.ReadFile(
-				mig.config.Fs,
-				fmt.Sprintf("%s/%s", mig.config.OverrideDirName, m.FileName),
-			)
-		} else {
-			contents, err = fs.ReadFile(mig.config.Fs, m.FileName)
-		}
+	migrations, err = readSource(source)
+	if err != nil {
+		return nil, nil, err
+	}
+	repeatables, err = source.Repeatables()
+	if err != nil {
+		return nil, nil, err
+	}
 
-		if err != nil {
-			return nil, err
-		}
-		m.raw = string(contents)
-		m.hash = hashRaw(m.raw)
+	return migrations, repeatables, nil
+}
 
-		m.Up, m.Down, err = splitRaw(
-			m.raw,
-			mig.config.UpDelimiter,
-			mig.config.DownDelimiter,
-		)
-		if err != nil {
-			return nil, err
-		}
+// fsSource creates a source for dir of fsys with the configured options
+func (mig *Mig) fsSource(fsys fs.FS, dir string) *FSSource {
+	return &FSSource{
+		Fs:            fsys,
+		Dir:           dir,
+		UpDelimiter:   mig.config.UpDelimiter,
+		DownDelimiter: mig.config.DownDelimiter,
+		Format:        mig.config.Format,
+		Recursive:     mig.config.Recursive,
+		Extensions:    mig.config.Extensions,
+		Ignore:        mig.config.Ignore,
+		Logger:        mig.config.Logger,
+		IDParser:      mig.config.IDParser,
+	}
+}
 
-		result = append(result, m)
+// storedOptions sets NoTransaction and SplitStatements of an applied SQL
+// migration, which are only kept as directives in its stored raw text
+func (mig *Mig) storedOptions(dbMig *Migration) {
+	if isGoRaw(dbMig.raw) {
+		return
 	}
+	m, err := MigFormat(mig.config.UpDelimiter, mig.config.DownDelimiter)(dbMig.raw)
+	if err != nil {
+		return
+	}
+	dbMig.NoTransaction = m.NoTransaction
+	dbMig.SplitStatements = m.SplitStatements
+}
 
-	sort.Slice(result, func(i, j int) bool {
-		return result[i].Id < result[j].Id
-	})
-	return result, nil
+// execSQL runs the up or down SQL of m, one statement at a time if m is
+// marked SplitStatements
+func execSQL(ctx context.Context, db execer, m Migration, sql string) error {
+	if !m.SplitStatements {
+		_, err := db.ExecContext(ctx, sql)
+		return err
+	}
+	for _, statement := range splitStatements(sql) {
+		_, err := db.ExecContext(ctx, statement)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
 }
 
 func (mig *Mig) getMigrationsFromDB() ([]Migration, error) {
-	rows, err := mig.config.Db.Query("SELECT * FROM migrations")
+	rows, err := mig.config.Db.Query(
+		fmt.Sprintf(`
+		SELECT
+			id, filename, raw, hash, up, down, applied_order, description, author, ticket, tags,
+			applied_at, execution_ms, applied_by, mig_version, hash_algorithm, requires
+		FROM
+			%s
+		WHERE
+			namespace = $1`, mig.migrationTable()),
+		mig.config.Namespace,
+	)
 	if err != nil {
 		return nil, err
 	}
@@ -308,7 +700,13 @@ func (mig *Mig) getMigrationsFromDB() ([]Migration, error) {
 
 	result := []Migration{}
 	for rows.Next() {
-		m := Migration{}
+		var (
+			m           = Migration{}
+			tags        string
+			requires    string
+			appliedAt   string
+			executionMs int64
+		)
 		err = rows.Scan(
 			&m.Id,
 			&m.FileName,
@@ -316,10 +714,28 @@ func (mig *Mig) getMigrationsFromDB() ([]Migration, error) {
 			&m.hash,
 			&m.Up,
 			&m.Down,
+			&m.appliedOrder,
+			&m.Description,
+			&m.Metadata.Author,
+			&m.Metadata.Ticket,
+			&tags,
+			&appliedAt,
+			&executionMs,
+			&m.execution.AppliedBy,
+			&m.execution.MigVersion,
+			&m.execution.HashAlgorithm,
+			&requires,
 		)
 		if err != nil {
 			return nil, err
 		}
+		err = scanExecution(&m.execution, appliedAt, executionMs)
+		if err != nil {
+			return nil, err
+		}
+		m.Metadata.Tags = splitList(tags)
+		m.Requires = splitList(requires)
+		mig.storedOptions(&m)
 		result = append(result, m)
 	}
 
@@ -41,101 +41,51 @@ func TestGetIntFromFileName(t *testing.T) {
 		f1 := "0001_create_table_users.sql"
 		got, err := getIntFromFileName(f1)
 		assert.Nil(t, err)
-		assert.Equal(t, got, 1)
+		assert.Equal(t, got, int64(1))
 
 		f2 := "12345_create_table_users.sql"
 		got, err = getIntFromFileName(f2)
 		assert.Nil(t, err)
-		assert.Equal(t, got, 12345)
+		assert.Equal(t, got, int64(12345))
 
 		f4 := "2_create_table_users.sql"
 		got, err = getIntFromFileName(f4)
 		assert.Nil(t, err)
-		assert.Equal(t, got, 2)
+		assert.Equal(t, got, int64(2))
 
 		f5 := "0002: create_table_users.sql"
 		got, err = getIntFromFileName(f5)
 		assert.Nil(t, err)
-		assert.Equal(t, got, 2)
+		assert.Equal(t, got, int64(2))
 
 		f7 := "03 - create_table_users.sql"
 		got, err = getIntFromFileName(f7)
 		assert.Nil(t, err)
-		assert.Equal(t, got, 3)
+		assert.Equal(t, got, int64(3))
+
+		f8 := "20261017093000_create_table_users.sql"
+		got, err = getIntFromFileName(f8)
+		assert.Nil(t, err)
+		assert.Equal(t, got, int64(20261017093000))
 	})
 
 	t.Run("fails on invalid filename", func(t *testing.T) {
 		f6 := "hello_create_table_users.sql"
 		got, err := getIntFromFileName(f6)
 		assert.NotNil(t, err)
-		assert.Equal(t, got, 0)
+		assert.Equal(t, got, int64(0))
 
 		f3 := "0000_create_table_users.sql"
 		got, err = getIntFromFileName(f3)
 		assert.NotNil(t, err)
-		assert.Equal(t, got, 0)
-	})
-
-}
-
-func TestGetDelimiterIndex(t *testing.T) {
-	t.Run("correctly finds delimiter index", func(t *testing.T) {
-		raw := `
-		-- up
-		CREATE TABLE users (id INTEGER PRIMARY KEY, name TEXT);
-
-		-- down
-		DROP TABLE users;
-		`
-
-		// for i, r := range raw {
-		// 	t.Logf("%d: %s", i, strconv.Quote(string(r)))
-		// }
+		assert.Equal(t, got, int64(0))
 
-		expected := 3
-		got, err := findDelimiterIndex(raw, DEFAULT_UP_DELIMITER)
-		assert.Nil(t, err)
-		assert.Equal(t, expected, got)
-
-		expected = 70
-		got, err = findDelimiterIndex(raw, DEFAULT_DOWN_DELIMITER)
-		assert.Nil(t, err)
-		assert.Equal(t, expected, got)
-	})
-
-	t.Run("fails if delimiter not found", func(t *testing.T) {
-		raw := `
-		-- up
-		CREATE TABLE users (id INTEGER PRIMARY KEY, name TEXT);
-		`
-
-		_, err := findDelimiterIndex(raw, DEFAULT_DOWN_DELIMITER)
+		f9 := "١٢_arabic_indic_digits.sql"
+		got, err = getIntFromFileName(f9)
 		assert.NotNil(t, err)
+		assert.Equal(t, got, int64(0))
 	})
-}
-
-func TestSplitRaw(t *testing.T) {
-	expectedUp := "CREATE TABLE users (id INTEGER PRIMARY KEY, name TEXT);"
-	expectedDown := "DROP TABLE users;"
-	raw := `-- up
-CREATE TABLE users (id INTEGER PRIMARY KEY, name TEXT);
--- down
-DROP TABLE users;`
-
-	up, down, err := splitRaw(raw, DEFAULT_UP_DELIMITER, DEFAULT_DOWN_DELIMITER)
-	assert.Nil(t, err)
-	assert.Equal(t, expectedUp, up)
-	assert.Equal(t, expectedDown, down)
 
-	raw = `-- down
-DROP TABLE users;
--- up
-CREATE TABLE users (id INTEGER PRIMARY KEY, name TEXT);`
-
-	up, down, err = splitRaw(raw, DEFAULT_UP_DELIMITER, DEFAULT_DOWN_DELIMITER)
-	assert.Nil(t, err)
-	assert.Equal(t, expectedUp, up)
-	assert.Equal(t, expectedDown, down)
 }
 
 func TestGetRaw(t *testing.T) {
@@ -149,3 +99,29 @@ DROP TABLE users;`
 	got := getRaw(up, down, DEFAULT_UP_DELIMITER, DEFAULT_DOWN_DELIMITER)
 	assert.Equal(t, expected, got)
 }
+
+func TestValidateSequence(t *testing.T) {
+	t.Run("duplicate ids are rejected naming both files", func(t *testing.T) {
+		migrations := []Migration{
+			{Id: 6, FileName: "0006_c.sql"},
+			{Id: 7, FileName: "0007_a.sql"},
+			{Id: 7, FileName: "7_b.sql"},
+		}
+
+		err := validateSequence(migrations, false)
+		assert.NotNil(t, err)
+		assert.Contains(t, err.Error(), "duplicate migration id 7 in 0007_a.sql and 7_b.sql")
+	})
+
+	t.Run("gaps are only rejected when asked to", func(t *testing.T) {
+		migrations := []Migration{{Id: 1}, {Id: 2}, {Id: 4, FileName: "0004_d.sql"}}
+
+		assert.Nil(t, validateSequence(migrations, false))
+
+		err := validateSequence(migrations, true)
+		assert.NotNil(t, err)
+		assert.Contains(t, err.Error(), "between 2 (migration 2) and 4 (0004_d.sql)")
+
+		assert.Nil(t, validateSequence(migrations[:2], true))
+	})
+}
@@ -0,0 +1,71 @@
+package mig
+
+import (
+	"database/sql"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNamespace(t *testing.T) {
+	t.Run("namespaces keep separate histories in one database", func(t *testing.T) {
+		testDbPath := "./test/test_namespace1.db"
+		db, err := sql.Open("sqlite3", testDbPath)
+		assert.Nil(t, err)
+		defer db.Close()
+
+		app, err := New(Config{
+			Db: db,
+			Migrations: []Migration{
+				{Id: 1, Up: "CREATE TABLE app1 (id INTEGER PRIMARY KEY);", Down: "DROP TABLE app1;"},
+				{Id: 2, Up: "CREATE TABLE app2 (id INTEGER PRIMARY KEY);", Down: "DROP TABLE app2;"},
+				{FileName: "R__app_view.sql", Up: "CREATE VIEW IF NOT EXISTS app_view AS SELECT 1 AS n;", Repeatable: true},
+			},
+		})
+		assert.Nil(t, err)
+		assert.Nil(t, app.Migrate())
+
+		lib, err := New(Config{
+			Db:        db,
+			Namespace: "lib",
+			Migrations: []Migration{
+				{Id: 1, Up: "CREATE TABLE lib1 (id INTEGER PRIMARY KEY);", Down: "DROP TABLE lib1;"},
+				{FileName: "R__app_view.sql", Up: "CREATE VIEW IF NOT EXISTS lib_view AS SELECT 1 AS n;", Repeatable: true},
+			},
+		})
+		assert.Nil(t, err)
+		assert.Nil(t, lib.Migrate())
+
+		tableMustExistSqlite(t, db, "app1")
+		tableMustExistSqlite(t, db, "lib1")
+		assert.Equal(t, 3, countRows(t, db, "migrations"))
+		assert.Equal(t, 2, countRows(t, db, repeatableTableName))
+
+		status, err := lib.Status()
+		assert.Nil(t, err)
+		assert.Len(t, status, 2)
+		status[0].Execution = Execution{}
+		assert.Equal(t, MigrationStatus{Id: 1, Applied: true, AppliedOrder: 1}, status[0])
+
+		// the application no longer having migration 2 does not touch the library
+		app.config.Migrations = app.config.Migrations[:1]
+		assert.Nil(t, app.Migrate())
+		tableMustNotExistSqlite(t, db, "app2")
+		tableMustExistSqlite(t, db, "lib1")
+
+		lib.config.Migrations = nil
+		assert.Nil(t, lib.Migrate())
+		tableMustNotExistSqlite(t, db, "lib1")
+		tableMustExistSqlite(t, db, "app1")
+
+		status, err = app.Status()
+		assert.Nil(t, err)
+		assert.Len(t, status, 2)
+		assert.True(t, status[0].Applied)
+		assert.False(t, status[1].Missing)
+
+		err = os.Remove(testDbPath)
+		assert.Nil(t, err)
+	})
+}
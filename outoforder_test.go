@@ -0,0 +1,132 @@
+package mig
+
+import (
+	"database/sql"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOutOfOrder(t *testing.T) {
+	migrations := func() []Migration {
+		return []Migration{
+			{
+				Id:   1,
+				Up:   "CREATE TABLE test1 (id INTEGER PRIMARY KEY, name TEXT);",
+				Down: "DROP TABLE test1;",
+			},
+			{
+				Id:   16,
+				Up:   "CREATE TABLE test16 (id INTEGER PRIMARY KEY, name TEXT);",
+				Down: "DROP TABLE test16;",
+			},
+		}
+	}
+	merged := Migration{
+		Id:   15,
+		Up:   "CREATE TABLE test15 (id INTEGER PRIMARY KEY, name TEXT);",
+		Down: "DROP TABLE test15;",
+	}
+
+	t.Run("lower ids are skipped and reported without AllowOutOfOrder", func(t *testing.T) {
+		testDbPath := "./test/test_outoforder1.db"
+		db, err := sql.Open("sqlite3", testDbPath)
+		assert.Nil(t, err)
+		defer db.Close()
+
+		m, err := New(Config{Db: db, Migrations: migrations()})
+		assert.Nil(t, err)
+		assert.Nil(t, m.Migrate())
+
+		m, err = New(Config{Db: db, Migrations: append(migrations(), merged)})
+		assert.Nil(t, err)
+
+		status, err := m.Status()
+		assert.Nil(t, err)
+		assert.Equal(t, int64(15), status[1].Id)
+		assert.True(t, status[1].OutOfOrder)
+		assert.False(t, status[1].Applied)
+
+		tableMustNotExistSqlite(t, db, "test15")
+
+		os.Remove(testDbPath)
+	})
+
+	t.Run("lower ids are applied with AllowOutOfOrder", func(t *testing.T) {
+		testDbPath := "./test/test_outoforder2.db"
+		db, err := sql.Open("sqlite3", testDbPath)
+		assert.Nil(t, err)
+		defer db.Close()
+
+		m, err := New(Config{Db: db, Migrations: migrations(), AllowOutOfOrder: true})
+		assert.Nil(t, err)
+		assert.Nil(t, m.Migrate())
+
+		m, err = New(Config{Db: db, Migrations: append(migrations(), merged), AllowOutOfOrder: true})
+		assert.Nil(t, err)
+
+		status, err := m.Status()
+		assert.Nil(t, err)
+		assert.True(t, status[1].OutOfOrder)
+
+		err = m.Migrate()
+		assert.Nil(t, err)
+		tableMustExistSqlite(t, db, "test15")
+
+		status, err = m.Status()
+		assert.Nil(t, err)
+		assert.Equal(t, []int64{1, 3, 2}, []int64{status[0].AppliedOrder, status[1].AppliedOrder, status[2].AppliedOrder})
+		assert.False(t, status[1].OutOfOrder)
+
+		err = m.Migrate()
+		assert.Nil(t, err, "migrating again must not fail on the id order")
+
+		// changing 15 rolls back every migration from 15 on and applies them again
+		m.config.Migrations[1].Up = "CREATE TABLE test15b (id INTEGER PRIMARY KEY, name TEXT);"
+		m.config.Migrations[1].Down = "DROP TABLE test15b;"
+		err = m.Migrate()
+		assert.Nil(t, err)
+		tableMustNotExistSqlite(t, db, "test15")
+		tableMustExistSqlite(t, db, "test15b")
+		tableMustExistSqlite(t, db, "test16")
+
+		status, err = m.Status()
+		assert.Nil(t, err)
+		assert.Equal(t, []int64{1, 2, 3}, []int64{status[0].AppliedOrder, status[1].AppliedOrder, status[2].AppliedOrder})
+
+		os.Remove(testDbPath)
+	})
+
+	t.Run("rollbacks follow the application order", func(t *testing.T) {
+		testDbPath := "./test/test_outoforder3.db"
+		db, err := sql.Open("sqlite3", testDbPath)
+		assert.Nil(t, err)
+		defer db.Close()
+
+		m, err := New(Config{Db: db, Migrations: migrations(), AllowOutOfOrder: true})
+		assert.Nil(t, err)
+		assert.Nil(t, m.Migrate())
+
+		// 15 depends on 16, which only works because 16 was applied first
+		dependent := Migration{
+			Id:   15,
+			Up:   "CREATE VIEW test15 AS SELECT name FROM test16;",
+			Down: "DROP VIEW test15;",
+		}
+		m, err = New(Config{Db: db, Migrations: append(migrations(), dependent), AllowOutOfOrder: true})
+		assert.Nil(t, err)
+		assert.Nil(t, m.Migrate())
+
+		m.config.Migrations = m.config.Migrations[:1]
+		err = m.Migrate()
+		assert.Nil(t, err)
+
+		rows, err := db.Query("SELECT name FROM sqlite_master WHERE name IN ('test15', 'test16')")
+		assert.Nil(t, err)
+		defer rows.Close()
+		assert.False(t, rows.Next())
+
+		os.Remove(testDbPath)
+	})
+}
@@ -0,0 +1,149 @@
+package mig
+
+import (
+	"fmt"
+	"strings"
+)
+
+// DIRECTIVE_PREFIX starts the lines of a migration file that configure the
+// migration instead of holding SQL, e.g. "-- mig:author jane"
+const DIRECTIVE_PREFIX = "-- mig:"
+
+// NO_TRANSACTION_DIRECTIVE runs a migration outside a transaction, e.g. for
+// CREATE INDEX CONCURRENTLY
+const NO_TRANSACTION_DIRECTIVE = "no-transaction"
+
+// SPLIT_STATEMENTS_DIRECTIVE runs each statement of a migration with its own
+// query, see Migration.SplitStatements
+const SPLIT_STATEMENTS_DIRECTIVE = "split-statements"
+
+// ParseError is a problem found at a line of a migration file
+type ParseError struct {
+	Line int
+	Err  error
+}
+
+func (e *ParseError) Error() string {
+	return fmt.Sprintf("line %d: %s", e.Line, e.Err)
+}
+
+func (e *ParseError) Unwrap() error {
+	return e.Err
+}
+
+// directive is a "-- mig:name value" line of a migration file
+type directive struct {
+	name  string
+	value string
+	line  int
+}
+
+// parsedMigration is a migration file split into its parts
+type parsedMigration struct {
+	up         string
+	down       string
+	directives []directive
+}
+
+// parseMigration splits the contents of a migration file into the up and down
+// SQL. Delimiters and directives are only recognized on lines of their own,
+// ignoring surrounding whitespace, so a comment like "-- upgrade the index"
+// is part of the SQL. A leading byte order mark and CRLF line endings are
+// tolerated, the SQL lines keep their line endings since they are hashed.
+// Directives and comments may come before the first delimiter, anything else
+// there is an error.
+func parseMigration(raw, upDelimiter, downDelimiter string) (parsedMigration, error) {
+	var (
+		result           parsedMigration
+		up, down         []string
+		section          *[]string
+		upLine, downLine int
+	)
+
+	raw = strings.TrimPrefix(raw, "\uFEFF")
+	for i, line := range strings.Split(raw, "\n") {
+		n := i + 1
+		trimmed := strings.TrimSpace(line)
+
+		switch {
+		case trimmed == upDelimiter:
+			if upLine != 0 {
+				return parsedMigration{}, &ParseError{n, fmt.Errorf("duplicate %q delimiter, first at line %d", upDelimiter, upLine)}
+			}
+			upLine = n
+			section = &up
+		case trimmed == downDelimiter:
+			if downLine != 0 {
+				return parsedMigration{}, &ParseError{n, fmt.Errorf("duplicate %q delimiter, first at line %d", downDelimiter, downLine)}
+			}
+			downLine = n
+			section = &down
+		case strings.HasPrefix(trimmed, DIRECTIVE_PREFIX):
+			if section != nil {
+				return parsedMigration{}, &ParseError{n, fmt.Errorf("directive %s must come before the first delimiter", trimmed)}
+			}
+			name, value, _ := strings.Cut(strings.TrimPrefix(trimmed, DIRECTIVE_PREFIX), " ")
+			if name == "" {
+				return parsedMigration{}, &ParseError{n, fmt.Errorf("directive without a name")}
+			}
+			result.directives = append(result.directives, directive{
+				name:  name,
+				value: strings.TrimSpace(value),
+				line:  n,
+			})
+		case section != nil:
+			*section = append(*section, line)
+		case trimmed != "" && !strings.HasPrefix(trimmed, "--"):
+			return parsedMigration{}, &ParseError{n, fmt.Errorf("SQL before the first delimiter")}
+		}
+	}
+
+	if upLine == 0 {
+		return parsedMigration{}, fmt.Errorf("no %q delimiter found", upDelimiter)
+	}
+	if downLine == 0 {
+		return parsedMigration{}, fmt.Errorf("no %q delimiter found", downDelimiter)
+	}
+
+	result.up = strings.TrimSpace(strings.Join(up, "\n"))
+	result.down = strings.TrimSpace(strings.Join(down, "\n"))
+	return result, nil
+}
+
+// applyDirectives sets the fields of m configured by the directives of its file
+func applyDirectives(m *Migration, directives []directive) error {
+	seen := map[string]int{}
+	for _, d := range directives {
+		if line, ok := seen[d.name]; ok && d.name != REQUIRES_DIRECTIVE {
+			return &ParseError{d.line, fmt.Errorf("duplicate directive %s%s, first at line %d", DIRECTIVE_PREFIX, d.name, line)}
+		}
+		seen[d.name] = d.line
+
+		switch d.name {
+		case NO_TRANSACTION_DIRECTIVE, SPLIT_STATEMENTS_DIRECTIVE:
+			if d.value != "" {
+				return &ParseError{d.line, fmt.Errorf("directive %s%s takes no value", DIRECTIVE_PREFIX, d.name)}
+			}
+			m.NoTransaction = m.NoTransaction || d.name == NO_TRANSACTION_DIRECTIVE
+			m.SplitStatements = m.SplitStatements || d.name == SPLIT_STATEMENTS_DIRECTIVE
+		case DESCRIPTION_DIRECTIVE:
+			m.Description = d.value
+		case AUTHOR_DIRECTIVE:
+			m.Metadata.Author = d.value
+		case TICKET_DIRECTIVE:
+			m.Metadata.Ticket = d.value
+		case TAGS_DIRECTIVE:
+			m.Metadata.Tags = splitList(d.value)
+		case REQUIRES_DIRECTIVE:
+			m.Requires = append(m.Requires, splitList(d.value)...)
+		default:
+			return &ParseError{d.line, fmt.Errorf("unknown directive %s%s", DIRECTIVE_PREFIX, d.name)}
+		}
+
+		if d.name != NO_TRANSACTION_DIRECTIVE && d.name != SPLIT_STATEMENTS_DIRECTIVE && d.value == "" {
+			return &ParseError{d.line, fmt.Errorf("directive %s%s needs a value", DIRECTIVE_PREFIX, d.name)}
+		}
+	}
+
+	return nil
+}
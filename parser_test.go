@@ -0,0 +1,183 @@
+package mig
+
+import (
+	"database/sql"
+	"os"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseMigration(t *testing.T) {
+	expectedUp := "CREATE TABLE users (id INTEGER PRIMARY KEY, name TEXT);"
+	expectedDown := "DROP TABLE users;"
+
+	t.Run("splits up and down in either order", func(t *testing.T) {
+		raw := `-- up
+CREATE TABLE users (id INTEGER PRIMARY KEY, name TEXT);
+-- down
+DROP TABLE users;`
+
+		parsed, err := parseMigration(raw, DEFAULT_UP_DELIMITER, DEFAULT_DOWN_DELIMITER)
+		assert.Nil(t, err)
+		assert.Equal(t, expectedUp, parsed.up)
+		assert.Equal(t, expectedDown, parsed.down)
+
+		raw = `-- down
+DROP TABLE users;
+-- up
+CREATE TABLE users (id INTEGER PRIMARY KEY, name TEXT);`
+
+		parsed, err = parseMigration(raw, DEFAULT_UP_DELIMITER, DEFAULT_DOWN_DELIMITER)
+		assert.Nil(t, err)
+		assert.Equal(t, expectedUp, parsed.up)
+		assert.Equal(t, expectedDown, parsed.down)
+	})
+
+	t.Run("delimiters are only recognized on their own line", func(t *testing.T) {
+		raw := `
+		-- the users table
+		-- up
+		-- upgrade the index too
+		CREATE TABLE users (id INTEGER PRIMARY KEY, name TEXT DEFAULT '-- down');
+
+		-- down
+		DROP TABLE users;
+		`
+
+		parsed, err := parseMigration(raw, DEFAULT_UP_DELIMITER, DEFAULT_DOWN_DELIMITER)
+		assert.Nil(t, err)
+		assert.Equal(t, "-- upgrade the index too\n\t\tCREATE TABLE users (id INTEGER PRIMARY KEY, name TEXT DEFAULT '-- down');", parsed.up)
+		assert.Equal(t, expectedDown, parsed.down)
+	})
+
+	t.Run("byte order mark and CRLF line endings are tolerated", func(t *testing.T) {
+		raw := "\uFEFF-- up\r\nCREATE TABLE users (id INTEGER PRIMARY KEY, name TEXT);\r\n-- down\r\nDROP TABLE users;\r\n"
+
+		parsed, err := parseMigration(raw, DEFAULT_UP_DELIMITER, DEFAULT_DOWN_DELIMITER)
+		assert.Nil(t, err)
+		assert.Equal(t, expectedUp, parsed.up)
+		assert.Equal(t, expectedDown, parsed.down)
+
+		parsed, err = parseMigration("-- up\r\nSELECT 1;\r\nSELECT 2;\r\n-- down\r\n", DEFAULT_UP_DELIMITER, DEFAULT_DOWN_DELIMITER)
+		assert.Nil(t, err)
+		assert.Equal(t, "SELECT 1;\r\nSELECT 2;", parsed.up, "SQL lines are kept byte for byte")
+	})
+
+	t.Run("directives are collected from the header", func(t *testing.T) {
+		raw := `-- mig:first one
+-- mig:second
+-- up
+SELECT 1;
+-- down
+SELECT 2;`
+
+		parsed, err := parseMigration(raw, DEFAULT_UP_DELIMITER, DEFAULT_DOWN_DELIMITER)
+		assert.Nil(t, err)
+		assert.Equal(t, []directive{
+			{name: "first", value: "one", line: 1},
+			{name: "second", line: 2},
+		}, parsed.directives)
+	})
+
+	t.Run("errors name the line", func(t *testing.T) {
+		tests := []struct {
+			raw  string
+			line int
+			err  string
+		}{
+			{"-- up\nSELECT 1;\n-- up\n-- down\n", 3, `line 3: duplicate "-- up" delimiter, first at line 1`},
+			{"-- up\n-- down\n\n-- down\n", 4, `line 4: duplicate "-- down" delimiter, first at line 2`},
+			{"SELECT 1;\n-- up\n-- down\n", 1, "line 1: SQL before the first delimiter"},
+			{"-- up\n-- mig:author jane\n-- down\n", 2, "line 2: directive -- mig:author jane must come before the first delimiter"},
+			{"-- mig:\n-- up\n-- down\n", 1, "line 1: directive without a name"},
+		}
+
+		for _, tt := range tests {
+			_, err := parseMigration(tt.raw, DEFAULT_UP_DELIMITER, DEFAULT_DOWN_DELIMITER)
+			assert.EqualError(t, err, tt.err)
+
+			var parseErr *ParseError
+			if assert.ErrorAs(t, err, &parseErr) {
+				assert.Equal(t, tt.line, parseErr.Line)
+			}
+		}
+	})
+
+	t.Run("fails if a delimiter is not found", func(t *testing.T) {
+		raw := `
+		-- up
+		CREATE TABLE users (id INTEGER PRIMARY KEY, name TEXT);
+		`
+
+		_, err := parseMigration(raw, DEFAULT_UP_DELIMITER, DEFAULT_DOWN_DELIMITER)
+		assert.EqualError(t, err, `no "-- down" delimiter found`)
+
+		_, err = parseMigration("-- upgrade\n-- down\n", DEFAULT_UP_DELIMITER, DEFAULT_DOWN_DELIMITER)
+		assert.EqualError(t, err, `no "-- up" delimiter found`)
+	})
+}
+
+func TestApplyDirectives(t *testing.T) {
+	m := Migration{}
+	err := applyDirectives(&m, []directive{{name: "bogus", line: 3}})
+	assert.EqualError(t, err, "line 3: unknown directive -- mig:bogus")
+}
+
+func TestParseMigrationHashes(t *testing.T) {
+	// legacySplitRaw is how mig split files before parseMigration
+	legacySplitRaw := func(raw string) (up, down string) {
+		downStart := strings.Index(raw, DEFAULT_DOWN_DELIMITER)
+		up = strings.TrimSpace(raw[len(DEFAULT_UP_DELIMITER):downStart])
+		down = strings.TrimSpace(raw[downStart+len(DEFAULT_DOWN_DELIMITER):])
+		return up, down
+	}
+
+	t.Run("CRLF migrations applied by older versions of mig are not rolled back", func(t *testing.T) {
+		testDbPath := "./test/test_parser1.db"
+		db, err := sql.Open("sqlite3", testDbPath)
+		assert.Nil(t, err)
+		defer db.Close()
+
+		fsys := fstest.MapFS{
+			"0001_users.sql": {Data: []byte("-- up\r\nCREATE TABLE users (id INTEGER PRIMARY KEY);\r\nINSERT INTO users (id) VALUES (1);\r\n-- down\r\nDROP TABLE users;\r\n")},
+			"0002_names.sql": {Data: []byte("-- up\r\nALTER TABLE users ADD COLUMN name TEXT;\r\n\r\n-- down\r\nALTER TABLE users DROP COLUMN name;\r\n")},
+		}
+
+		// the tracking table and rows as the first versions of mig wrote them
+		_, err = db.Exec("CREATE TABLE migrations (id SERIAL PRIMARY KEY, filename TEXT, raw TEXT, hash TEXT, up TEXT, down TEXT)")
+		assert.Nil(t, err)
+		for i, name := range []string{"0001_users.sql", "0002_names.sql"} {
+			up, down := legacySplitRaw(string(fsys[name].Data))
+			_, err = db.Exec(up)
+			assert.Nil(t, err)
+
+			raw := getRaw(up, down, DEFAULT_UP_DELIMITER, DEFAULT_DOWN_DELIMITER)
+			_, err = db.Exec(
+				"INSERT INTO migrations (id, filename, raw, hash, up, down) VALUES ($1, $2, $3, $4, $5, $6)",
+				i+1, name, raw, hashRaw(raw), up, down,
+			)
+			assert.Nil(t, err)
+		}
+		_, err = db.Exec("INSERT INTO users (id, name) VALUES (2, 'jane')")
+		assert.Nil(t, err)
+
+		m, err := New(Config{Db: db, Fs: fsys})
+		assert.Nil(t, err)
+
+		status, err := m.Status()
+		assert.Nil(t, err)
+		for _, s := range status {
+			assert.False(t, s.Changed, s.Diff)
+		}
+
+		err = m.Migrate()
+		assert.Nil(t, err)
+		assert.Equal(t, 2, countRows(t, db, "users"), "nothing was rolled back")
+
+		err = os.Remove(testDbPath)
+		assert.Nil(t, err)
+	})
+}
@@ -40,6 +40,103 @@ func TestPostgres(t *testing.T) {
 		tableMustExistPostgres(t, db, "test_table_3")
 		tableMustNotExistPostgres(t, db, "test_table_4")
 	})
+
+	t.Run("baseline recreates schema", func(t *testing.T) {
+		err := startPostgresContainer()
+		assert.NoError(t, err)
+		defer stopPostgresContainer()
+
+		db, err := getPostgresConnection()
+		assert.NoError(t, err)
+		defer db.Close()
+
+		_, err = db.Exec(`
+			CREATE TABLE users (id SERIAL PRIMARY KEY, name TEXT NOT NULL UNIQUE);
+			CREATE TABLE orders (id SERIAL PRIMARY KEY, user_id INTEGER REFERENCES users(id));
+			CREATE INDEX orders_user_id ON orders (user_id);
+			CREATE VIEW user_names AS SELECT name FROM users;
+		`)
+		assert.NoError(t, err)
+
+		m, err := New(Config{Db: db})
+		if err != nil {
+			t.Fatalf("failed to create mig: %v", err)
+		}
+
+		baseline, err := m.Baseline()
+		assert.NoError(t, err)
+
+		_, err = db.Exec(baseline.Down)
+		assert.NoError(t, err)
+		tableMustNotExistPostgres(t, db, "users")
+		tableMustNotExistPostgres(t, db, "orders")
+
+		_, err = db.Exec(baseline.Up)
+		assert.NoError(t, err)
+		tableMustExistPostgres(t, db, "users")
+		tableMustExistPostgres(t, db, "orders")
+	})
+
+	t.Run("tracking tables in a schema", func(t *testing.T) {
+		err := startPostgresContainer()
+		assert.NoError(t, err)
+		defer stopPostgresContainer()
+
+		db, err := getPostgresConnection()
+		assert.NoError(t, err)
+		defer db.Close()
+
+		_, err = db.Exec("CREATE SCHEMA tracking")
+		assert.NoError(t, err)
+
+		m, err := New(Config{
+			Db:        db,
+			Fs:        os.DirFS("./test/migrations1"),
+			TableName: "schema_versions",
+			Schema:    "tracking",
+		})
+		if err != nil {
+			t.Fatalf("failed to create mig: %v", err)
+		}
+
+		err = m.Migrate()
+		assert.NoError(t, err)
+
+		var count int
+		err = db.QueryRow("SELECT COUNT(*) FROM tracking.schema_versions").Scan(&count)
+		assert.NoError(t, err)
+		assert.Equal(t, 3, count)
+		tableMustNotExistPostgres(t, db, "migrations")
+		tableMustExistPostgres(t, db, "test_table_1")
+	})
+
+	t.Run("legacy tracking table is upgraded", func(t *testing.T) {
+		err := startPostgresContainer()
+		assert.NoError(t, err)
+		defer stopPostgresContainer()
+
+		db, err := getPostgresConnection()
+		assert.NoError(t, err)
+		defer db.Close()
+
+		_, err = db.Exec("CREATE TABLE migrations (id SERIAL PRIMARY KEY, filename TEXT, raw TEXT, hash TEXT, up TEXT, down TEXT)")
+		assert.NoError(t, err)
+
+		m, err := New(Config{Db: db, Fs: os.DirFS("./test/migrations1")})
+		if err != nil {
+			t.Fatalf("failed to create mig: %v", err)
+		}
+
+		err = m.Migrate()
+		assert.NoError(t, err)
+		tableMustExistPostgres(t, db, "test_table_3")
+		tableMustNotExistPostgres(t, db, "migrations_upgrade")
+
+		var pkey int
+		err = db.QueryRow("SELECT COUNT(*) FROM pg_indexes WHERE indexname = 'migrations_pkey'").Scan(&pkey)
+		assert.NoError(t, err)
+		assert.Equal(t, 1, pkey)
+	})
 }
 
 func startPostgresContainer() error {
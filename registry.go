@@ -0,0 +1,111 @@
+package mig
+
+import (
+	"fmt"
+	"path/filepath"
+	"runtime"
+	"sync"
+)
+
+// DEFAULT_GO_VERSION is the version of Go migrations registered without one
+const DEFAULT_GO_VERSION = "1"
+
+// DefaultRegistry holds the Go migrations registered with Register
+var DefaultRegistry = NewRegistry()
+
+// Registry collects Go migrations registered from init functions, taking the
+// id of each migration from the name of the file that registers it, e.g.
+// 0012_backfill_slugs.go registers migration 12. A Registry is a Source.
+type Registry struct {
+	mu         sync.Mutex
+	migrations SliceSource
+
+	// err is the first registration error, reported by List
+	err error
+}
+
+// NewRegistry creates an empty registry
+func NewRegistry() *Registry {
+	return &Registry{}
+}
+
+// Register adds a Go migration to DefaultRegistry, see Registry.Register
+func Register(up, down MigrationFunc) {
+	DefaultRegistry.register(DEFAULT_GO_VERSION, up, down)
+}
+
+// RegisterVersion adds a Go migration to DefaultRegistry, see Registry.RegisterVersion
+func RegisterVersion(version string, up, down MigrationFunc) {
+	DefaultRegistry.register(version, up, down)
+}
+
+// Register adds a Go migration with the default version. It must be called
+// from the file whose name holds the id of the migration.
+func (r *Registry) Register(up, down MigrationFunc) {
+	r.register(DEFAULT_GO_VERSION, up, down)
+}
+
+// RegisterVersion is like Register with an explicit version, change it when
+// the functions change to apply the migration again
+func (r *Registry) RegisterVersion(version string, up, down MigrationFunc) {
+	r.register(version, up, down)
+}
+
+// register must be called directly by the exported register functions, so
+// the caller two frames up is the file registering the migration
+func (r *Registry) register(version string, up, down MigrationFunc) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	_, file, _, ok := runtime.Caller(2)
+	if !ok {
+		r.setErr(fmt.Errorf("mig: cannot determine the file registering a go migration"))
+		return
+	}
+
+	m := Migration{
+		FileName: filepath.Base(file),
+		UpFunc:   up,
+		DownFunc: down,
+		Version:  version,
+	}
+
+	var err error
+	m.Id, err = getIntFromFileName(m.FileName)
+	if err != nil {
+		r.setErr(fmt.Errorf("mig: error registering go migration in %s: %w", m.FileName, err))
+		return
+	}
+
+	for _, other := range r.migrations {
+		if other.Id == m.Id {
+			r.setErr(fmt.Errorf("mig: duplicate migration id %d in %s and %s", m.Id, other.FileName, m.FileName))
+			return
+		}
+	}
+
+	r.migrations = append(r.migrations, m)
+}
+
+func (r *Registry) setErr(err error) {
+	if r.err == nil {
+		r.err = err
+	}
+}
+
+func (r *Registry) List() ([]int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.err != nil {
+		return nil, r.err
+	}
+	return r.migrations.List()
+}
+
+func (r *Registry) Read(id int64) (Migration, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.migrations.Read(id)
+}
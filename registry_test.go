@@ -0,0 +1,71 @@
+package mig_test
+
+import (
+	"context"
+	"database/sql"
+	"os"
+	"testing"
+
+	"github.com/cprosche/mig"
+	"github.com/cprosche/mig/test/gomigrations"
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRegistry(t *testing.T) {
+	t.Run("registered go migrations take their id from the file name", func(t *testing.T) {
+		ids, err := gomigrations.Registry.List()
+		assert.Nil(t, err)
+		assert.ElementsMatch(t, []int64{4, 10}, ids)
+
+		m, err := gomigrations.Registry.Read(4)
+		assert.Nil(t, err)
+		assert.Equal(t, "0004_seed_users.go", m.FileName)
+		assert.Equal(t, mig.DEFAULT_GO_VERSION, m.Version)
+
+		m, err = gomigrations.Registry.Read(10)
+		assert.Nil(t, err)
+		assert.Equal(t, "2", m.Version)
+	})
+
+	t.Run("registering from a file without an id fails on list", func(t *testing.T) {
+		r := mig.NewRegistry()
+		r.Register(func(ctx context.Context, tx *sql.Tx) error { return nil }, nil)
+
+		_, err := r.List()
+		assert.NotNil(t, err)
+		assert.Contains(t, err.Error(), "registry_test.go")
+	})
+
+	t.Run("registered go migrations run with sql migrations", func(t *testing.T) {
+		testDbPath := "./test/test_registry1.db"
+		db, err := sql.Open("sqlite3", testDbPath)
+		assert.Nil(t, err)
+		defer db.Close()
+
+		m, err := mig.New(mig.Config{
+			Db: db,
+			Source: mig.MultiSource(
+				mig.NewFSSource(os.DirFS("./test/migrations1"), ""),
+				gomigrations.Registry,
+			),
+		})
+		assert.Nil(t, err)
+
+		err = m.Migrate()
+		assert.Nil(t, err)
+
+		var names []string
+		rows, err := db.Query("SELECT name FROM test_table_1 ORDER BY name")
+		assert.Nil(t, err)
+		defer rows.Close()
+		for rows.Next() {
+			var name string
+			assert.Nil(t, rows.Scan(&name))
+			names = append(names, name)
+		}
+		assert.Equal(t, []string{"ALICE", "BOB"}, names)
+
+		os.Remove(testDbPath)
+	})
+}
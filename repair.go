@@ -0,0 +1,182 @@
+package mig
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+const (
+	repairTableName   = "migration_repairs"
+	repairTableSuffix = "_repairs"
+)
+
+var repairTableSchema = `
+		CREATE TABLE IF NOT EXISTS %s (
+			namespace TEXT NOT NULL DEFAULT '',
+			migration_id BIGINT,
+			filename TEXT,
+			repaired_at TEXT,
+			repaired_by TEXT,
+			old_hash TEXT,
+			new_hash TEXT,
+			diff TEXT
+		)
+`
+
+// RepairResult describes a migration whose stored copy was updated by Repair
+type RepairResult struct {
+	Id      int64
+	OldHash string
+	NewHash string
+
+	// Diff is a unified diff from the stored migration to the current one
+	Diff string
+}
+
+// repairName is the name of the repair table, migration_repairs unless
+// Config.TableName names the tracking table otherwise
+func (mig *Mig) repairName() string {
+	if mig.tableName() == migrationTableName {
+		return repairTableName
+	}
+	return mig.tableName() + repairTableSuffix
+}
+
+func (mig *Mig) repairTable() string {
+	return mig.table(mig.repairName())
+}
+
+// Repair updates the stored raw, hash, up and down of already applied
+// migrations to match the current source, without running any SQL. Use it
+// after an intentional edit that must not trigger a rollback, such as fixing
+// a typo in a down section. If no ids are given, every applied migration
+// whose hash changed is repaired. Each repair is recorded in the
+// migration_repairs table, see Config.TableName.
+func (mig *Mig) Repair(ids ...int64) ([]RepairResult, error) {
+	return mig.RepairContext(context.Background(), ids...)
+}
+
+// RepairContext is like Repair, ctx is passed on to the queries
+func (mig *Mig) RepairContext(ctx context.Context, ids ...int64) ([]RepairResult, error) {
+	mig.assignRawAndHashes()
+
+	dbMigrations, err := mig.getMigrationsFromDB()
+	if err != nil {
+		return nil, fmt.Errorf("mig: error getting migrations from db: %w", err)
+	}
+
+	applied := make(map[int64]Migration, len(dbMigrations))
+	for _, m := range dbMigrations {
+		applied[m.Id] = m
+	}
+	current := make(map[int64]Migration, len(mig.config.Migrations))
+	for _, m := range mig.config.Migrations {
+		current[m.Id] = m
+	}
+
+	if len(ids) == 0 {
+		for _, m := range dbMigrations {
+			if c, ok := current[m.Id]; ok && c.hash != m.hash {
+				ids = append(ids, m.Id)
+			}
+		}
+	}
+
+	var toRepair []Migration
+	for _, id := range ids {
+		if _, ok := applied[id]; !ok {
+			return nil, fmt.Errorf("mig: cannot repair migration %d, it has not been applied", id)
+		}
+		m, ok := current[id]
+		if !ok {
+			return nil, fmt.Errorf("mig: cannot repair migration %d, it is not in the source", id)
+		}
+		toRepair = append(toRepair, m)
+	}
+
+	var (
+		result []RepairResult
+		actor  = mig.actor()
+		now    = time.Now().UTC().Format(time.RFC3339)
+	)
+	err = mig.inTx(ctx, func(tx *sql.Tx) error {
+		for _, m := range toRepair {
+			old := applied[m.Id]
+			if old.hash == m.hash {
+				continue
+			}
+
+			r := RepairResult{
+				Id:      m.Id,
+				OldHash: old.hash,
+				NewHash: m.hash,
+				Diff:    migrationDiff(old, m),
+			}
+
+			_, err := tx.ExecContext(
+				ctx,
+				fmt.Sprintf(`
+		UPDATE %s
+		SET filename = $1, raw = $2, hash = $3, up = $4, down = $5, description = $6, author = $7, ticket = $8, tags = $9, requires = $10
+		WHERE namespace = $11 AND id = $12`, mig.migrationTable()),
+				m.FileName,
+				m.raw,
+				m.hash,
+				m.Up,
+				m.Down,
+				m.Description,
+				m.Metadata.Author,
+				m.Metadata.Ticket,
+				joinList(m.Metadata.Tags),
+				joinList(m.Requires),
+				mig.config.Namespace,
+				m.Id,
+			)
+			if err != nil {
+				return fmt.Errorf("mig: error repairing migration %d: %w", m.Id, err)
+			}
+
+			_, err = tx.ExecContext(
+				ctx,
+				fmt.Sprintf(`
+		INSERT INTO
+			%s (namespace, migration_id, filename, repaired_at, repaired_by, old_hash, new_hash, diff)
+		VALUES
+			($1, $2, $3, $4, $5, $6, $7, $8)`, mig.repairTable()),
+				mig.config.Namespace,
+				m.Id,
+				m.FileName,
+				now,
+				actor,
+				r.OldHash,
+				r.NewHash,
+				r.Diff,
+			)
+			if err != nil {
+				return fmt.Errorf("mig: error recording repair of migration %d: %w", m.Id, err)
+			}
+
+			err = mig.recordHistory(ctx, tx, HistoryEntry{
+				Operation: HISTORY_REPAIR,
+				Id:        m.Id,
+				FileName:  m.FileName,
+				OldHash:   r.OldHash,
+				NewHash:   r.NewHash,
+				Outcome:   OUTCOME_SUCCESS,
+			})
+			if err != nil {
+				return fmt.Errorf("mig: %w", err)
+			}
+
+			result = append(result, r)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
@@ -0,0 +1,88 @@
+package mig
+
+import (
+	"context"
+	"database/sql"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRepair(t *testing.T) {
+	t.Run("repair updates stored migration without rolling back", func(t *testing.T) {
+		testDbPath := "./test/test_repair1.db"
+		db, err := sql.Open("sqlite3", testDbPath)
+		assert.Nil(t, err)
+		defer db.Close()
+
+		m, err := New(Config{
+			Db: db,
+			Migrations: []Migration{
+				{
+					Id:       1,
+					FileName: "0001_users.sql",
+					Up:       "CREATE TABLE test1 (id INTEGER PRIMARY KEY, name TEXT);",
+					Down:     "DROP TABEL test1;",
+				},
+				{
+					Id:   2,
+					Up:   "CREATE TABLE test2 (id INTEGER PRIMARY KEY, name TEXT);",
+					Down: "DROP TABLE test2;",
+				},
+			},
+		})
+		assert.Nil(t, err)
+
+		err = m.Migrate()
+		assert.Nil(t, err)
+
+		_, err = db.Exec("INSERT INTO test1 (name) VALUES ('kept')")
+		assert.Nil(t, err)
+
+		m.config.Migrations[0].Down = "DROP TABLE test1;"
+
+		_, err = m.Repair(3)
+		assert.NotNil(t, err)
+
+		// a canceled repair changes nothing
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		_, err = m.RepairContext(ctx)
+		assert.ErrorIs(t, err, context.Canceled)
+		status, err := m.Status()
+		assert.Nil(t, err)
+		assert.True(t, status[0].Changed)
+
+		results, err := m.Repair()
+		assert.Nil(t, err)
+		assert.Len(t, results, 1)
+		assert.Equal(t, int64(1), results[0].Id)
+		assert.NotEqual(t, results[0].OldHash, results[0].NewHash)
+		assert.Contains(t, results[0].Diff, "-DROP TABEL test1;\n+DROP TABLE test1;\n")
+
+		var count int
+		err = db.QueryRow("SELECT COUNT(*) FROM migration_repairs WHERE migration_id = 1").Scan(&count)
+		assert.Nil(t, err)
+		assert.Equal(t, 1, count)
+
+		var fileName string
+		err = db.QueryRow("SELECT filename FROM migration_repairs WHERE migration_id = 1").Scan(&fileName)
+		assert.Nil(t, err)
+		assert.Equal(t, "0001_users.sql", fileName)
+
+		// nothing left to repair, and migrating keeps the data
+		results, err = m.Repair(1)
+		assert.Nil(t, err)
+		assert.Len(t, results, 0)
+
+		err = m.Migrate()
+		assert.Nil(t, err)
+
+		err = db.QueryRow("SELECT COUNT(*) FROM test1").Scan(&count)
+		assert.Nil(t, err)
+		assert.Equal(t, 1, count)
+
+		os.Remove(testDbPath)
+	})
+}
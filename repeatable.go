@@ -0,0 +1,158 @@
+package mig
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"sort"
+	"time"
+)
+
+const (
+	repeatableTableSuffix = "_repeatable"
+	repeatableTableName   = migrationTableName + repeatableTableSuffix
+)
+
+var repeatableTableSchema = `
+		CREATE TABLE IF NOT EXISTS %s (
+			namespace TEXT NOT NULL DEFAULT '',
+			filename TEXT,
+			raw TEXT,
+			hash TEXT,
+			up TEXT,
+			description TEXT,
+			PRIMARY KEY (namespace, filename)
+		)
+`
+
+func (mig *Mig) repeatableTable() string {
+	return mig.table(mig.tableName() + repeatableTableSuffix)
+}
+
+// splitRepeatables separates repeatable migrations from versioned ones
+func splitRepeatables(migrations []Migration) (versioned []Migration, repeatables []Migration) {
+	for _, m := range migrations {
+		if m.Repeatable {
+			repeatables = append(repeatables, m)
+		} else {
+			versioned = append(versioned, m)
+		}
+	}
+
+	sort.SliceStable(repeatables, func(i, j int) bool {
+		return repeatables[i].FileName < repeatables[j].FileName
+	})
+	return versioned, repeatables
+}
+
+// validateRepeatables checks that repeatable migrations, which must be sorted
+// by file name, are named uniquely and only hold up SQL
+func validateRepeatables(repeatables []Migration) error {
+	for i, m := range repeatables {
+		if m.FileName == "" {
+			return fmt.Errorf("mig: repeatable migration must have a file name")
+		}
+		if i > 0 && repeatables[i-1].FileName == m.FileName {
+			return fmt.Errorf("mig: duplicate repeatable migration %s", m.FileName)
+		}
+		if m.UpFunc != nil || m.DownFunc != nil {
+			return fmt.Errorf("mig: repeatable migration %s cannot have Go functions", m.FileName)
+		}
+		if m.Down != "" {
+			return fmt.Errorf("mig: repeatable migration %s cannot have a down migration", m.FileName)
+		}
+	}
+
+	return nil
+}
+
+// runRepeatables applies every repeatable migration that is new or whose
+// hash changed since it was last applied, in file name order. Repeatables
+// that are no longer in the source are left alone, as they have nothing to
+// roll back.
+func (mig *Mig) runRepeatables(ctx context.Context) error {
+	applied, err := mig.getRepeatablesFromDB()
+	if err != nil {
+		return fmt.Errorf("mig: error getting repeatable migrations from db: %w", err)
+	}
+
+	for _, m := range mig.repeatables {
+		dbMig, ok := applied[m.FileName]
+		if ok && dbMig.hash == m.hash {
+			continue
+		}
+
+		entry := HistoryEntry{Operation: HISTORY_REPEATABLE, FileName: m.FileName, OldHash: dbMig.hash, NewHash: m.hash, SQL: m.Up}
+		start := time.Now()
+		_, err = mig.config.Db.ExecContext(ctx, m.Up)
+		if err != nil {
+			return mig.recordFailure(ctx, entry, start, fmt.Errorf("mig: error running repeatable migration %s: %w", m.FileName, err))
+		}
+
+		err = mig.inTx(ctx, func(tx *sql.Tx) error {
+			_, err := tx.ExecContext(
+				ctx,
+				fmt.Sprintf("DELETE FROM %s WHERE namespace = $1 AND filename = $2", mig.repeatableTable()),
+				mig.config.Namespace,
+				m.FileName,
+			)
+			if err != nil {
+				return err
+			}
+
+			_, err = tx.ExecContext(
+				ctx,
+				fmt.Sprintf(`
+		INSERT INTO
+			%s (namespace, filename, raw, hash, up, description)
+		VALUES
+			($1, $2, $3, $4, $5, $6)`, mig.repeatableTable()),
+				mig.config.Namespace,
+				m.FileName,
+				m.raw,
+				m.hash,
+				m.Up,
+				m.Description,
+			)
+			if err != nil {
+				return err
+			}
+			return mig.recordHistory(ctx, tx, entry.succeeded(start))
+		})
+		if err != nil {
+			return mig.recordFailure(ctx, entry, start, fmt.Errorf("mig: error recording repeatable migration %s: %w", m.FileName, err))
+		}
+	}
+
+	return nil
+}
+
+// getRepeatablesFromDB returns the applied repeatable migrations by file name
+func (mig *Mig) getRepeatablesFromDB() (map[string]Migration, error) {
+	rows, err := mig.config.Db.Query(
+		fmt.Sprintf("SELECT filename, raw, hash, up, description FROM %s WHERE namespace = $1", mig.repeatableTable()),
+		mig.config.Namespace,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	result := map[string]Migration{}
+	for rows.Next() {
+		m := Migration{Repeatable: true}
+		err = rows.Scan(
+			&m.FileName,
+			&m.raw,
+			&m.hash,
+			&m.Up,
+			&m.Description,
+		)
+		if err != nil {
+			return nil, err
+		}
+		result[m.FileName] = m
+	}
+
+	return result, rows.Err()
+}
@@ -0,0 +1,160 @@
+package mig
+
+import (
+	"database/sql"
+	"os"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRepeatable(t *testing.T) {
+	t.Run("repeatables are applied after versioned migrations and again when changed", func(t *testing.T) {
+		testDbPath := "./test/test_repeatable1.db"
+		db, err := sql.Open("sqlite3", testDbPath)
+		assert.Nil(t, err)
+		defer db.Close()
+
+		migrations := []Migration{
+			{
+				FileName:   "R__order_count.sql",
+				Up:         "DROP VIEW IF EXISTS order_count; CREATE VIEW order_count AS SELECT COUNT(*) AS n FROM orders;",
+				Repeatable: true,
+			},
+			{
+				Id:   1,
+				Up:   "CREATE TABLE orders (id INTEGER PRIMARY KEY, total INTEGER);",
+				Down: "DROP TABLE orders;",
+			},
+		}
+
+		m, err := New(Config{Db: db, Migrations: migrations})
+		assert.Nil(t, err)
+		assert.Len(t, m.config.Migrations, 1)
+		assert.Len(t, m.repeatables, 1)
+
+		err = m.Migrate()
+		assert.Nil(t, err)
+		assert.Equal(t, 1, countRows(t, db, "order_count"))
+		assert.Equal(t, 1, countRows(t, db, repeatableTableName))
+
+		// unchanged repeatables are not applied again
+		_, err = db.Exec("DROP VIEW order_count")
+		assert.Nil(t, err)
+		err = m.Migrate()
+		assert.Nil(t, err)
+		_, err = db.Exec("SELECT * FROM order_count")
+		assert.NotNil(t, err)
+
+		migrations[0].Up = "DROP VIEW IF EXISTS order_count; CREATE VIEW order_count AS SELECT COUNT(*) AS n, SUM(total) AS total FROM orders;"
+		m, err = New(Config{Db: db, Migrations: migrations})
+		assert.Nil(t, err)
+
+		status, err := m.Status()
+		assert.Nil(t, err)
+		assert.Len(t, status, 2)
+		assert.True(t, status[1].Repeatable)
+		assert.True(t, status[1].Changed)
+		assert.Contains(t, status[1].Diff, "+DROP VIEW IF EXISTS order_count; CREATE VIEW order_count AS SELECT COUNT(*) AS n, SUM(total) AS total FROM orders;\n")
+
+		err = m.Migrate()
+		assert.Nil(t, err)
+		_, err = db.Exec("SELECT total FROM order_count")
+		assert.Nil(t, err)
+		assert.Equal(t, 1, countRows(t, db, repeatableTableName))
+
+		status, err = m.Status()
+		assert.Nil(t, err)
+		assert.Equal(t, MigrationStatus{FileName: "R__order_count.sql", Repeatable: true, Applied: true}, status[1])
+
+		err = os.Remove(testDbPath)
+		assert.Nil(t, err)
+	})
+
+	t.Run("repeatables are read from R__ files", func(t *testing.T) {
+		testDbPath := "./test/test_repeatable2.db"
+		db, err := sql.Open("sqlite3", testDbPath)
+		assert.Nil(t, err)
+		defer db.Close()
+
+		m, err := New(Config{Db: db, Fs: os.DirFS("./test/migrations7")})
+		assert.Nil(t, err)
+		assert.Len(t, m.config.Migrations, 1)
+		assert.Len(t, m.repeatables, 1)
+		assert.Equal(t, "order summary view", m.repeatables[0].Description)
+
+		err = m.Migrate()
+		assert.Nil(t, err)
+		tableMustExistSqlite(t, db, "orders")
+		assert.Equal(t, 1, countRows(t, db, "order_summary"))
+
+		err = os.Remove(testDbPath)
+		assert.Nil(t, err)
+	})
+
+	t.Run("repeatables that are no longer in the source are reported as missing", func(t *testing.T) {
+		testDbPath := "./test/test_repeatable3.db"
+		db, err := sql.Open("sqlite3", testDbPath)
+		assert.Nil(t, err)
+		defer db.Close()
+
+		m, err := New(Config{
+			Db: db,
+			Migrations: []Migration{
+				{FileName: "R__answer.sql", Up: "CREATE VIEW IF NOT EXISTS answer AS SELECT 42 AS n;", Repeatable: true},
+			},
+		})
+		assert.Nil(t, err)
+		err = m.Migrate()
+		assert.Nil(t, err)
+
+		m, err = New(Config{Db: db})
+		assert.Nil(t, err)
+		err = m.Migrate()
+		assert.Nil(t, err)
+
+		status, err := m.Status()
+		assert.Nil(t, err)
+		assert.Equal(t, []MigrationStatus{{FileName: "R__answer.sql", Repeatable: true, Applied: true, Missing: true}}, status)
+
+		err = os.Remove(testDbPath)
+		assert.Nil(t, err)
+	})
+
+	t.Run("invalid repeatables are rejected", func(t *testing.T) {
+		testDbPath := "./test/test_repeatable4.db"
+		db, err := sql.Open("sqlite3", testDbPath)
+		assert.Nil(t, err)
+		defer db.Close()
+
+		_, err = New(Config{
+			Db: db,
+			Migrations: []Migration{
+				{FileName: "R__a.sql", Up: "SELECT 1;", Repeatable: true},
+				{FileName: "R__a.sql", Up: "SELECT 2;", Repeatable: true},
+			},
+		})
+		assert.ErrorContains(t, err, "duplicate repeatable migration R__a.sql")
+
+		_, err = New(Config{
+			Db: db,
+			Migrations: []Migration{
+				{FileName: "R__a.sql", Up: "SELECT 1;", Down: "SELECT 2;", Repeatable: true},
+			},
+		})
+		assert.ErrorContains(t, err, "cannot have a down migration")
+
+		_, err = New(Config{
+			Db: db,
+			Source: MultiSource(
+				SliceSource{{FileName: "R__a.sql", Up: "SELECT 1;", Repeatable: true}},
+				NewFSSource(fstest.MapFS{"R__a.sql": {Data: []byte("SELECT 2;")}}, ""),
+			),
+		})
+		assert.ErrorContains(t, err, "duplicate repeatable migration R__a.sql in source 1 and source 2")
+
+		err = os.Remove(testDbPath)
+		assert.Nil(t, err)
+	})
+}
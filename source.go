@@ -0,0 +1,828 @@
+package mig
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"log/slog"
+	"path"
+	"slices"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	DEFAULT_EXTENSION   = ".sql"
+	MIGIGNORE_FILE_NAME = ".migignore"
+
+	// Suffixes before the extension marking separate up and down files
+	UP_FILE_SUFFIX   = ".up"
+	DOWN_FILE_SUFFIX = ".down"
+
+	// Names of the parts of a migration folder, see FSSource.readFolder
+	FOLDER_UP             = "up"
+	FOLDER_DOWN           = "down"
+	FOLDER_META_FILE_NAME = "meta.yaml"
+
+	// FOLDER_FILE_COMMENT precedes the name of each file of a migration folder
+	FOLDER_FILE_COMMENT = "-- file: "
+
+	// REPEATABLE_PREFIX starts the names of repeatable migration files,
+	// e.g. R__order_summary_view.sql
+	REPEATABLE_PREFIX = "R__"
+)
+
+var discardLogger = slog.New(slog.NewTextHandler(io.Discard, nil))
+
+// Source provides the migrations Mig works with
+type Source interface {
+	// List returns the ids of all migrations in the source
+	List() ([]int64, error)
+
+	// Read returns the migration with the given id
+	Read(id int64) (Migration, error)
+}
+
+// RepeatableSource is implemented by sources that also provide repeatable
+// migrations, see Migration.Repeatable
+type RepeatableSource interface {
+	Source
+
+	// Repeatables returns all repeatable migrations in the source
+	Repeatables() ([]Migration, error)
+}
+
+// FSSource reads migrations from the files in a directory of a filesystem.
+// Each file holds the up and down migration, split by the delimiters, or a
+// migration is split into an up and a down file like golang-migrate does,
+// e.g. 0001_create_users.up.sql and 0001_create_users.down.sql. Large
+// migrations can also be a folder with up and down parts and a meta.yaml.
+// Files starting with R__ are repeatable migrations, the whole file is
+// their SQL.
+type FSSource struct {
+	Fs fs.FS
+
+	// Dir is the directory with the migrations, the root of Fs if empty
+	Dir string
+
+	// Delimiters for splitting up and down migrations, the defaults if empty
+	UpDelimiter   string
+	DownDelimiter string
+
+	// Format parses single migration files, MigFormat with the delimiters if nil
+	Format Format
+
+	// Recursive makes the source include the files in subdirectories of Dir,
+	// e.g. to group migrations by year or module. File names are then paths
+	// relative to Dir, and ids must still be unique across all directories.
+	Recursive bool
+
+	// Extensions are the file name suffixes of migrations, only .sql files
+	// are migrations if empty
+	Extensions []string
+
+	// Ignore holds glob patterns, see path.Match, of files and directories
+	// to skip. They are matched against both the path relative to Dir and
+	// the base name. More patterns are read from a .migignore file in Dir,
+	// one per line, with # starting a comment.
+	Ignore []string
+
+	// Logger receives a debug message for every skipped file, if set
+	Logger *slog.Logger
+
+	// IDParser extracts ids and descriptions from file names,
+	// LeadingIntIDParser if nil
+	IDParser IDParser
+
+	// files maps ids to their files, it is filled by List
+	files map[int64]*migrationFiles
+
+	// repeatables are the names of the repeatable migration files, filled by List
+	repeatables []string
+}
+
+// migrationFiles are the files a migration is read from, either a single
+// file with delimiters or a pair of up and down files, like
+// 0001_create_users.up.sql and 0001_create_users.down.sql
+type migrationFiles struct {
+	single string
+	up     string
+	down   string
+
+	// folder is a directory holding the files of the migration, see readFolder
+	folder string
+
+	// pairName is the name of a pair without the .up or .down part
+	pairName string
+
+	// description is taken from the name of the first file
+	description string
+}
+
+func (f *migrationFiles) names() string {
+	var names []string
+	for _, name := range []string{f.single, f.up, f.down, f.folder} {
+		if name != "" {
+			names = append(names, name)
+		}
+	}
+	return strings.Join(names, " and ")
+}
+
+// NewFSSource creates a source for the migrations in dir of fsys using the
+// default delimiters
+func NewFSSource(fsys fs.FS, dir string) *FSSource {
+	return &FSSource{
+		Fs:  fsys,
+		Dir: dir,
+	}
+}
+
+func (s *FSSource) List() ([]int64, error) {
+	s.files = map[int64]*migrationFiles{}
+	s.repeatables = nil
+
+	ignore, err := s.ignorePatterns()
+	if err != nil {
+		return nil, err
+	}
+
+	var ids []int64
+	entry := func(name string) (int64, *migrationFiles, error) {
+		id, description, err := s.parseID(path.Base(name))
+		if err != nil {
+			return 0, nil, fmt.Errorf("%w: %s", err, name)
+		}
+
+		f, ok := s.files[id]
+		if !ok {
+			f = &migrationFiles{description: description}
+			s.files[id] = f
+			ids = append(ids, id)
+		}
+		return id, f, nil
+	}
+	addFolder := func(name string) error {
+		id, f, err := entry(name)
+		if err != nil {
+			return err
+		}
+		if f.names() != "" {
+			return fmt.Errorf("mig: duplicate migration id %d in %s and %s", id, f.names(), name)
+		}
+		f.folder = name
+		return nil
+	}
+	add := func(name string) error {
+		if pattern, ok := matchAny(ignore, name); ok {
+			s.logger().Debug("mig: ignoring file", "file", name, "pattern", pattern)
+			return nil
+		}
+		base, ok := s.trimExtension(name)
+		if !ok {
+			s.logger().Debug("mig: ignoring file without migration extension", "file", name)
+			return nil
+		}
+		if strings.HasPrefix(path.Base(name), REPEATABLE_PREFIX) {
+			s.repeatables = append(s.repeatables, name)
+			return nil
+		}
+
+		id, f, err := entry(name)
+		if err != nil {
+			return err
+		}
+
+		var half *string
+		switch {
+		case strings.HasSuffix(base, UP_FILE_SUFFIX):
+			half = &f.up
+			base = strings.TrimSuffix(base, UP_FILE_SUFFIX)
+		case strings.HasSuffix(base, DOWN_FILE_SUFFIX):
+			half = &f.down
+			base = strings.TrimSuffix(base, DOWN_FILE_SUFFIX)
+		default:
+			if f.single != "" || f.folder != "" {
+				return fmt.Errorf("mig: duplicate migration id %d in %s and %s", id, f.names(), name)
+			}
+			if f.up != "" || f.down != "" {
+				return fmt.Errorf("mig: migration %d mixes a single file and up/down files: %s and %s", id, f.names(), name)
+			}
+			f.single = name
+			return nil
+		}
+
+		if f.single != "" {
+			return fmt.Errorf("mig: migration %d mixes a single file and up/down files: %s and %s", id, f.names(), name)
+		}
+		if *half != "" || f.folder != "" || (f.pairName != "" && f.pairName != base) {
+			return fmt.Errorf("mig: duplicate migration id %d in %s and %s", id, f.names(), name)
+		}
+		*half = name
+		f.pairName = base
+		return nil
+	}
+
+	if s.Recursive {
+		err := fs.WalkDir(s.Fs, s.dir(), func(p string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if !d.IsDir() {
+				return add(s.relativePath(p))
+			}
+			if p == s.dir() {
+				return nil
+			}
+
+			if pattern, ok := matchAny(ignore, s.relativePath(p)); ok {
+				s.logger().Debug("mig: ignoring directory", "dir", s.relativePath(p), "pattern", pattern)
+				return fs.SkipDir
+			}
+			if s.isFolder(p) {
+				err = addFolder(s.relativePath(p))
+				if err != nil {
+					return err
+				}
+				return fs.SkipDir
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		entries, err := fs.ReadDir(s.Fs, s.dir())
+		if err != nil {
+			return nil, err
+		}
+		for _, entry := range entries {
+			if entry.IsDir() {
+				if pattern, ok := matchAny(ignore, entry.Name()); ok {
+					s.logger().Debug("mig: ignoring directory", "dir", entry.Name(), "pattern", pattern)
+					continue
+				}
+				if !s.isFolder(path.Join(s.dir(), entry.Name())) {
+					s.logger().Debug("mig: ignoring directory", "dir", entry.Name())
+					continue
+				}
+
+				err = addFolder(entry.Name())
+				if err != nil {
+					return nil, err
+				}
+				continue
+			}
+
+			err = add(entry.Name())
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	for _, id := range ids {
+		f := s.files[id]
+		if f.up != "" && f.down == "" {
+			return nil, fmt.Errorf("mig: up file %s has no matching down file", f.up)
+		}
+		if f.down != "" && f.up == "" {
+			return nil, fmt.Errorf("mig: down file %s has no matching up file", f.down)
+		}
+	}
+
+	return ids, nil
+}
+
+func (s *FSSource) Read(id int64) (Migration, error) {
+	if s.files == nil {
+		_, err := s.List()
+		if err != nil {
+			return Migration{}, err
+		}
+	}
+
+	f, ok := s.files[id]
+	if !ok {
+		return Migration{}, fmt.Errorf("mig: migration %d not found in fs", id)
+	}
+
+	if f.folder != "" {
+		return s.readFolder(id, f)
+	}
+	if f.single == "" {
+		return s.readPair(id, f)
+	}
+
+	contents, err := fs.ReadFile(s.Fs, path.Join(s.dir(), f.single))
+	if err != nil {
+		return Migration{}, err
+	}
+
+	m, err := s.format()(string(contents))
+	if err != nil {
+		return Migration{}, fmt.Errorf("mig: error reading %s: %w", f.single, err)
+	}
+	m.Id = id
+	m.FileName = f.single
+	m.Description = defaultString(m.Description, f.description)
+	m.raw = string(contents)
+	m.hash = hashRaw(m.raw)
+
+	return m, nil
+}
+
+// Repeatables reads the repeatable migration files, ordered by name
+func (s *FSSource) Repeatables() ([]Migration, error) {
+	if s.files == nil {
+		_, err := s.List()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	result := make([]Migration, 0, len(s.repeatables))
+	for _, name := range s.repeatables {
+		contents, err := fs.ReadFile(s.Fs, path.Join(s.dir(), name))
+		if err != nil {
+			return nil, err
+		}
+
+		result = append(result, Migration{
+			FileName:    name,
+			Description: describe(strings.TrimPrefix(path.Base(name), REPEATABLE_PREFIX)),
+			Up:          strings.TrimSpace(string(contents)),
+			Repeatable:  true,
+		})
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].FileName < result[j].FileName
+	})
+	return result, nil
+}
+
+// readPair reads a migration from separate up and down files, which are
+// hashed together
+func (s *FSSource) readPair(id int64, f *migrationFiles) (Migration, error) {
+	up, err := fs.ReadFile(s.Fs, path.Join(s.dir(), f.up))
+	if err != nil {
+		return Migration{}, err
+	}
+	down, err := fs.ReadFile(s.Fs, path.Join(s.dir(), f.down))
+	if err != nil {
+		return Migration{}, err
+	}
+
+	m := Migration{
+		Id:          id,
+		FileName:    f.up,
+		Description: f.description,
+		Up:          strings.TrimSpace(string(up)),
+		Down:        strings.TrimSpace(string(down)),
+	}
+	m.raw = getRaw(
+		m.Up,
+		m.Down,
+		defaultString(s.UpDelimiter, DEFAULT_UP_DELIMITER),
+		defaultString(s.DownDelimiter, DEFAULT_DOWN_DELIMITER),
+	)
+	m.hash = hashRaw(m.raw)
+
+	return m, nil
+}
+
+// isFolder reports whether the directory p holds a single migration, which
+// is the case if it has an up file or an up directory
+func (s *FSSource) isFolder(p string) bool {
+	if _, _, err := s.parseID(path.Base(p)); err != nil {
+		return false
+	}
+
+	entries, err := fs.ReadDir(s.Fs, p)
+	if err != nil {
+		return false
+	}
+	for _, entry := range entries {
+		if entry.IsDir() && entry.Name() == FOLDER_UP {
+			return true
+		}
+		if base, ok := s.trimExtension(entry.Name()); ok && !entry.IsDir() && base == FOLDER_UP {
+			return true
+		}
+	}
+	return false
+}
+
+// readFolder reads a migration from a folder like
+//
+//	0042_orders/
+//	    up/01_schema.sql
+//	    up/02_backfill.sql
+//	    down.sql
+//	    meta.yaml
+//
+// The up and down parts are either a single file or a directory of files,
+// which are concatenated in name order, each starting with a comment naming
+// it, and hashed together. The down part and meta.yaml are optional, a
+// description in meta.yaml replaces the one from the folder name, its
+// author, ticket and tags are the Metadata, and requires lists the
+// migrations it depends on.
+func (s *FSSource) readFolder(id int64, f *migrationFiles) (Migration, error) {
+	var (
+		err    error
+		folder = f.folder
+	)
+
+	m := Migration{
+		Id:          id,
+		FileName:    folder,
+		Description: f.description,
+	}
+
+	m.Up, err = s.readFolderPart(folder, FOLDER_UP)
+	if err != nil {
+		return Migration{}, err
+	}
+	m.Down, err = s.readFolderPart(folder, FOLDER_DOWN)
+	if err != nil {
+		return Migration{}, err
+	}
+
+	contents, err := fs.ReadFile(s.Fs, path.Join(s.dir(), folder, FOLDER_META_FILE_NAME))
+	if err == nil {
+		meta, err := parseFolderMeta(contents)
+		if err != nil {
+			return Migration{}, fmt.Errorf("mig: error reading %s: %w", path.Join(folder, FOLDER_META_FILE_NAME), err)
+		}
+		m.Description = defaultString(meta.Description, m.Description)
+		m.Metadata = Metadata{
+			Author: meta.Author,
+			Ticket: meta.Ticket,
+			Tags:   meta.Tags,
+		}
+		m.Requires = meta.Requires
+	} else if !errors.Is(err, fs.ErrNotExist) {
+		return Migration{}, err
+	}
+
+	m.raw = getRaw(
+		m.Up,
+		m.Down,
+		defaultString(s.UpDelimiter, DEFAULT_UP_DELIMITER),
+		defaultString(s.DownDelimiter, DEFAULT_DOWN_DELIMITER),
+	)
+	m.hash = hashRaw(m.raw)
+
+	return m, nil
+}
+
+// readFolderPart reads the up or down part of a migration folder, it is
+// empty if the folder has neither a file nor a directory for it
+func (s *FSSource) readFolderPart(folder, part string) (string, error) {
+	entries, err := fs.ReadDir(s.Fs, path.Join(s.dir(), folder))
+	if err != nil {
+		return "", err
+	}
+
+	var file, dir string
+	for _, entry := range entries {
+		if entry.IsDir() && entry.Name() == part {
+			dir = path.Join(folder, part)
+		}
+		if base, ok := s.trimExtension(entry.Name()); ok && !entry.IsDir() && base == part {
+			file = path.Join(folder, entry.Name())
+		}
+	}
+
+	switch {
+	case file != "" && dir != "":
+		return "", fmt.Errorf("mig: migration folder %s has both %s and %s", folder, file, dir)
+	case file != "":
+		contents, err := fs.ReadFile(s.Fs, path.Join(s.dir(), file))
+		if err != nil {
+			return "", err
+		}
+		return strings.TrimSpace(string(contents)), nil
+	case dir == "":
+		return "", nil
+	}
+
+	entries, err = fs.ReadDir(s.Fs, path.Join(s.dir(), dir))
+	if err != nil {
+		return "", err
+	}
+
+	var parts []string
+	for _, entry := range entries {
+		name := path.Join(dir, entry.Name())
+		if _, ok := s.trimExtension(entry.Name()); !ok || entry.IsDir() {
+			s.logger().Debug("mig: ignoring file in migration folder", "file", name)
+			continue
+		}
+
+		contents, err := fs.ReadFile(s.Fs, path.Join(s.dir(), name))
+		if err != nil {
+			return "", err
+		}
+		parts = append(parts, FOLDER_FILE_COMMENT+name+"\n"+strings.TrimSpace(string(contents)))
+	}
+	if len(parts) == 0 {
+		return "", fmt.Errorf("mig: migration folder %s has no files in %s", folder, dir)
+	}
+
+	return strings.Join(parts, "\n\n"), nil
+}
+
+// folderMeta is the content of the optional meta.yaml of a migration folder
+type folderMeta struct {
+	Description string   `yaml:"description"`
+	Author      string   `yaml:"author"`
+	Ticket      string   `yaml:"ticket"`
+	Tags        []string `yaml:"tags"`
+	Requires    []string `yaml:"requires"`
+}
+
+func parseFolderMeta(contents []byte) (folderMeta, error) {
+	var meta folderMeta
+
+	decoder := yaml.NewDecoder(bytes.NewReader(contents))
+	decoder.KnownFields(true)
+	err := decoder.Decode(&meta)
+	if errors.Is(err, io.EOF) {
+		return meta, nil
+	}
+	return meta, err
+}
+
+func (s *FSSource) parseID(fileName string) (int64, string, error) {
+	if s.IDParser == nil {
+		return LeadingIntIDParser(fileName)
+	}
+	return s.IDParser(fileName)
+}
+
+func (s *FSSource) format() Format {
+	if s.Format == nil {
+		return MigFormat(s.UpDelimiter, s.DownDelimiter)
+	}
+	return s.Format
+}
+
+func (s *FSSource) dir() string {
+	return defaultString(s.Dir, ".")
+}
+
+// ignorePatterns returns the Ignore patterns and those in the .migignore file
+func (s *FSSource) ignorePatterns() ([]string, error) {
+	patterns := append([]string{}, s.Ignore...)
+
+	contents, err := fs.ReadFile(s.Fs, path.Join(s.dir(), MIGIGNORE_FILE_NAME))
+	if errors.Is(err, fs.ErrNotExist) {
+		return patterns, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("mig: error reading %s: %w", MIGIGNORE_FILE_NAME, err)
+	}
+
+	for _, line := range strings.Split(string(contents), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		patterns = append(patterns, line)
+	}
+
+	for _, pattern := range patterns {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return nil, fmt.Errorf("mig: invalid ignore pattern %q: %w", pattern, err)
+		}
+	}
+	return patterns, nil
+}
+
+// trimExtension removes the migration extension from name, ok is false if
+// name has none of them
+func (s *FSSource) trimExtension(name string) (base string, ok bool) {
+	extensions := s.Extensions
+	if len(extensions) == 0 {
+		extensions = []string{DEFAULT_EXTENSION}
+	}
+
+	for _, ext := range extensions {
+		if strings.HasSuffix(name, ext) {
+			return strings.TrimSuffix(name, ext), true
+		}
+	}
+	return "", false
+}
+
+func (s *FSSource) logger() *slog.Logger {
+	if s.Logger == nil {
+		return discardLogger
+	}
+	return s.Logger
+}
+
+// relativePath returns p relative to the directory of the source
+func (s *FSSource) relativePath(p string) string {
+	if s.dir() == "." {
+		return p
+	}
+	return strings.TrimPrefix(p, s.dir()+"/")
+}
+
+// matchAny returns the first pattern matching either the path or the base
+// name of name
+func matchAny(patterns []string, name string) (string, bool) {
+	for _, pattern := range patterns {
+		if ok, _ := path.Match(pattern, name); ok {
+			return pattern, true
+		}
+		if ok, _ := path.Match(pattern, path.Base(name)); ok {
+			return pattern, true
+		}
+	}
+	return "", false
+}
+
+// SliceSource provides migrations from memory
+type SliceSource []Migration
+
+func (s SliceSource) List() ([]int64, error) {
+	var ids []int64
+	for _, m := range s {
+		if !m.Repeatable {
+			ids = append(ids, m.Id)
+		}
+	}
+	return ids, nil
+}
+
+func (s SliceSource) Read(id int64) (Migration, error) {
+	for _, m := range s {
+		if m.Id == id && !m.Repeatable {
+			return m, nil
+		}
+	}
+	return Migration{}, fmt.Errorf("mig: migration %d not found", id)
+}
+
+func (s SliceSource) Repeatables() ([]Migration, error) {
+	_, repeatables := splitRepeatables(s)
+	return repeatables, nil
+}
+
+// FuncSource provides the migrations returned by a Go function, which is
+// called once per List, e.g. to build migrations from templates
+type FuncSource func() ([]Migration, error)
+
+func (f FuncSource) List() ([]int64, error) {
+	migrations, err := f()
+	if err != nil {
+		return nil, err
+	}
+	return SliceSource(migrations).List()
+}
+
+func (f FuncSource) Read(id int64) (Migration, error) {
+	migrations, err := f()
+	if err != nil {
+		return Migration{}, err
+	}
+	return SliceSource(migrations).Read(id)
+}
+
+func (f FuncSource) Repeatables() ([]Migration, error) {
+	migrations, err := f()
+	if err != nil {
+		return nil, err
+	}
+	return SliceSource(migrations).Repeatables()
+}
+
+// multiSource merges several sources into one
+type multiSource struct {
+	sources []Source
+
+	// owner maps ids to the index of the source providing them, it is filled by List
+	owner map[int64]int
+}
+
+// MultiSource merges several sources into one, e.g. the migrations shipped by
+// a shared library and the application's own. Listing fails if an id is
+// provided by more than one source.
+func MultiSource(sources ...Source) Source {
+	return &multiSource{sources: sources}
+}
+
+func (s *multiSource) List() ([]int64, error) {
+	s.owner = map[int64]int{}
+
+	var ids []int64
+	for i, source := range s.sources {
+		sourceIds, err := source.List()
+		if err != nil {
+			return nil, err
+		}
+
+		for _, id := range sourceIds {
+			if other, ok := s.owner[id]; ok {
+				return nil, s.duplicateError(id, other, i)
+			}
+			s.owner[id] = i
+			ids = append(ids, id)
+		}
+	}
+
+	slices.Sort(ids)
+	return ids, nil
+}
+
+func (s *multiSource) Read(id int64) (Migration, error) {
+	if s.owner == nil {
+		_, err := s.List()
+		if err != nil {
+			return Migration{}, err
+		}
+	}
+
+	i, ok := s.owner[id]
+	if !ok {
+		return Migration{}, fmt.Errorf("mig: migration %d not found", id)
+	}
+	return s.sources[i].Read(id)
+}
+
+// Repeatables merges the repeatable migrations of all sources, failing if two
+// share a file name
+func (s *multiSource) Repeatables() ([]Migration, error) {
+	var result []Migration
+	owner := map[string]int{}
+	for i, source := range s.sources {
+		repeatables, err := readRepeatables(source)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, m := range repeatables {
+			if other, ok := owner[m.FileName]; ok {
+				return nil, fmt.Errorf("mig: duplicate repeatable migration %s in source %d and source %d", m.FileName, other+1, i+1)
+			}
+			owner[m.FileName] = i
+			result = append(result, m)
+		}
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].FileName < result[j].FileName
+	})
+	return result, nil
+}
+
+// duplicateError names the migrations that share an id in two sources
+func (s *multiSource) duplicateError(id int64, first, second int) error {
+	name := func(i int) string {
+		m, err := s.sources[i].Read(id)
+		if err != nil || m.FileName == "" {
+			return fmt.Sprintf("source %d", i+1)
+		}
+		return fmt.Sprintf("%s (source %d)", m.FileName, i+1)
+	}
+	return fmt.Errorf("mig: duplicate migration id %d in %s and %s", id, name(first), name(second))
+}
+
+// readSource reads all migrations of a source, ordered by id
+func readSource(s Source) ([]Migration, error) {
+	ids, err := s.List()
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]Migration, 0, len(ids))
+	for _, id := range ids {
+		m, err := s.Read(id)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, m)
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Id < result[j].Id
+	})
+	return result, nil
+}
+
+// readRepeatables reads the repeatable migrations of a source, if it has any
+func readRepeatables(s Source) ([]Migration, error) {
+	r, ok := s.(RepeatableSource)
+	if !ok {
+		return nil, nil
+	}
+	return r.Repeatables()
+}
@@ -0,0 +1,429 @@
+package mig
+
+import (
+	"bytes"
+	"database/sql"
+	"errors"
+	"log/slog"
+	"os"
+	"path"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSources(t *testing.T) {
+	t.Run("fs source reads migrations from a directory", func(t *testing.T) {
+		migrations, err := readSource(NewFSSource(migrationsFS, "test/migrations1"))
+		assert.Nil(t, err)
+		assert.Len(t, migrations, 3)
+		assert.Equal(t, "1_file_1.sql", migrations[0].FileName)
+		assert.Equal(t, "3 - file 3.sql", migrations[2].FileName)
+		assert.Equal(t, "DROP TABLE test_table_3;", migrations[2].Down)
+
+		_, err = NewFSSource(migrationsFS, "test/migrations1").Read(4)
+		assert.NotNil(t, err)
+	})
+
+	t.Run("func source calls the function", func(t *testing.T) {
+		source := FuncSource(func() ([]Migration, error) {
+			return []Migration{{Id: 2, Up: "SELECT 2;"}, {Id: 1, Up: "SELECT 1;"}}, nil
+		})
+
+		migrations, err := readSource(source)
+		assert.Nil(t, err)
+		assert.Equal(t, int64(1), migrations[0].Id)
+		assert.Equal(t, int64(2), migrations[1].Id)
+
+		failing := FuncSource(func() ([]Migration, error) {
+			return nil, errors.New("boom")
+		})
+		_, err = readSource(failing)
+		assert.NotNil(t, err)
+	})
+
+	t.Run("multi source merges sources in id order", func(t *testing.T) {
+		source := MultiSource(
+			SliceSource{{Id: 10, Up: "SELECT 10;"}},
+			NewFSSource(os.DirFS("./test/migrations1"), ""),
+		)
+
+		migrations, err := readSource(source)
+		assert.Nil(t, err)
+		assert.Len(t, migrations, 4)
+		for i, id := range []int64{1, 2, 3, 10} {
+			assert.Equal(t, id, migrations[i].Id)
+		}
+	})
+
+	t.Run("multi source fails on duplicate ids", func(t *testing.T) {
+		source := MultiSource(
+			NewFSSource(os.DirFS("./test/migrations1"), ""),
+			SliceSource{{Id: 2, FileName: "0002_library.sql"}},
+		)
+
+		_, err := source.List()
+		assert.NotNil(t, err)
+		assert.Contains(t, err.Error(), "2_file_2.sql (source 1)")
+		assert.Contains(t, err.Error(), "0002_library.sql (source 2)")
+	})
+
+	t.Run("migrating from a multi source works", func(t *testing.T) {
+		testDbPath := "./test/test_source1.db"
+		db, err := sql.Open("sqlite3", testDbPath)
+		assert.Nil(t, err)
+		defer db.Close()
+
+		m, err := New(Config{
+			Db: db,
+			Source: MultiSource(
+				NewFSSource(migrationsFS, "test/migrations1"),
+				SliceSource{
+					{
+						Id:   4,
+						Up:   "CREATE TABLE test_table_4 (id INTEGER PRIMARY KEY, name TEXT);",
+						Down: "DROP TABLE test_table_4;",
+					},
+				},
+			),
+		})
+		assert.Nil(t, err)
+
+		err = m.Migrate()
+		assert.Nil(t, err)
+
+		tableMustExistSqlite(t, db, "test_table_1")
+		tableMustExistSqlite(t, db, "test_table_3")
+		tableMustExistSqlite(t, db, "test_table_4")
+
+		os.Remove(testDbPath)
+	})
+}
+
+func TestRecursiveFSSource(t *testing.T) {
+	t.Run("recursive source orders migrations across directories", func(t *testing.T) {
+		source := NewFSSource(os.DirFS("./test/migrations2"), "")
+		source.Recursive = true
+
+		migrations, err := readSource(source)
+		assert.Nil(t, err)
+		assert.Len(t, migrations, 4)
+
+		expected := []string{"0001_root.sql", "2025/0002_users.sql", "billing/0003_invoices.sql", "2025/0004_orders.sql"}
+		for i, name := range expected {
+			assert.Equal(t, int64(i+1), migrations[i].Id)
+			assert.Equal(t, name, migrations[i].FileName)
+		}
+	})
+
+	t.Run("non recursive source skips directories", func(t *testing.T) {
+		migrations, err := readSource(NewFSSource(os.DirFS("./test/migrations2"), ""))
+		assert.Nil(t, err)
+		assert.Len(t, migrations, 1)
+	})
+
+	t.Run("recursive source fails on duplicate ids across directories", func(t *testing.T) {
+		source := NewFSSource(migrationsFS, "test/migrations1")
+		source.Recursive = true
+
+		_, err := source.List()
+		assert.NotNil(t, err)
+		assert.Contains(t, err.Error(), "1_file_1.sql")
+		assert.Contains(t, err.Error(), "decoy_folder/1 decoy sql.sql")
+
+		source = NewFSSource(os.DirFS("./test/migrations3"), "")
+		source.Recursive = true
+
+		_, err = source.List()
+		assert.NotNil(t, err)
+		assert.Contains(t, err.Error(), "a/1_first.sql and b/0001_second.sql")
+
+		db, err := sql.Open("sqlite3", "./test/test_recursive3.db")
+		assert.Nil(t, err)
+		defer db.Close()
+
+		file := &fstest.MapFile{Data: []byte("-- up\nSELECT 1;\n-- down\nSELECT 1;\n")}
+		_, err = New(Config{Db: db, Fs: fstest.MapFS{"2025/0007_a.sql": file, "2026/0007_b.sql": file}, Recursive: true})
+		assert.EqualError(t, err, "mig: error getting migrations from fs: mig: duplicate migration id 7 in 2025/0007_a.sql and 2026/0007_b.sql")
+
+		db.Close()
+		err = os.Remove("./test/test_recursive3.db")
+		assert.Nil(t, err)
+	})
+
+	t.Run("recursive migrations from config work", func(t *testing.T) {
+		testDbPath := "./test/test_recursive1.db"
+		db, err := sql.Open("sqlite3", testDbPath)
+		assert.Nil(t, err)
+		defer db.Close()
+
+		m, err := New(Config{
+			Db:        db,
+			Fs:        os.DirFS("./test"),
+			Recursive: true,
+
+			OverrideDirName: "migrations2",
+		})
+		assert.Nil(t, err)
+
+		err = m.Migrate()
+		assert.Nil(t, err)
+
+		for _, table := range []string{"recursive_1", "recursive_2", "recursive_3", "recursive_4"} {
+			tableMustExistSqlite(t, db, table)
+		}
+
+		var fileName string
+		err = db.QueryRow("SELECT filename FROM migrations WHERE id = 3").Scan(&fileName)
+		assert.Nil(t, err)
+		assert.Equal(t, "billing/0003_invoices.sql", fileName)
+
+		os.Remove(testDbPath)
+	})
+
+	t.Run("moving a migration into a folder keeps it applied", func(t *testing.T) {
+		testDbPath := "./test/test_recursive2.db"
+		db, err := sql.Open("sqlite3", testDbPath)
+		assert.Nil(t, err)
+		defer db.Close()
+
+		file := &fstest.MapFile{Data: []byte("-- up\nCREATE TABLE test1 (id INTEGER PRIMARY KEY);\n-- down\nDROP TABLE test1;\n")}
+		for _, allowOutOfOrder := range []bool{false, true} {
+			m, err := New(Config{Db: db, Fs: fstest.MapFS{"0001_a.sql": file}, Recursive: true, AllowOutOfOrder: allowOutOfOrder})
+			assert.Nil(t, err)
+			err = m.Migrate()
+			assert.Nil(t, err)
+			_, err = db.Exec("INSERT INTO test1 (id) VALUES (1)")
+			assert.Nil(t, err)
+
+			m, err = New(Config{Db: db, Fs: fstest.MapFS{"2025/0001_a.sql": file}, Recursive: true, AllowOutOfOrder: allowOutOfOrder})
+			assert.Nil(t, err)
+			status, err := m.Status()
+			assert.Nil(t, err)
+			assert.Len(t, status, 1)
+			assert.True(t, status[0].Applied)
+
+			err = m.Migrate()
+			assert.Nil(t, err)
+			assert.Equal(t, 1, countRows(t, db, "test1"), "the table must not be rolled back")
+
+			_, err = db.Exec("DELETE FROM test1")
+			assert.Nil(t, err)
+		}
+
+		err = os.Remove(testDbPath)
+		assert.Nil(t, err)
+	})
+}
+
+func TestFSSourceFiltering(t *testing.T) {
+	t.Run("only migration files are read", func(t *testing.T) {
+		var logs bytes.Buffer
+		source := NewFSSource(os.DirFS("./test/migrations4"), "")
+		source.Recursive = true
+		source.Logger = slog.New(slog.NewTextHandler(&logs, &slog.HandlerOptions{Level: slog.LevelDebug}))
+
+		migrations, err := readSource(source)
+		assert.Nil(t, err)
+		assert.Len(t, migrations, 2)
+		assert.Equal(t, "0001_users.sql", migrations[0].FileName)
+		assert.Equal(t, "0002_orders.sql", migrations[1].FileName)
+
+		for _, name := range []string{"README.md", ".gitkeep", ".migignore", "0002_orders.sql.swp", "0003_wip_draft.sql", "dir=drafts"} {
+			assert.Contains(t, logs.String(), name)
+		}
+	})
+
+	t.Run("extensions and ignore patterns can be configured", func(t *testing.T) {
+		source := NewFSSource(os.DirFS("./test/migrations4"), "")
+		source.Extensions = []string{".sql", ".md"}
+		source.Ignore = []string{"README*", "0001_*"}
+
+		migrations, err := readSource(source)
+		assert.Nil(t, err)
+		assert.Len(t, migrations, 1)
+		assert.Equal(t, int64(2), migrations[0].Id)
+
+		source.Extensions = []string{".md"}
+		source.Ignore = nil
+		_, err = readSource(source)
+		assert.NotNil(t, err, "README.md has no id")
+		assert.Contains(t, err.Error(), "README.md")
+
+		source.Extensions = nil
+		source.Ignore = []string{"["}
+		_, err = readSource(source)
+		assert.ErrorIs(t, err, path.ErrBadPattern)
+	})
+}
+
+func TestFSSourceUpDownFiles(t *testing.T) {
+	t.Run("up and down files are read as one migration", func(t *testing.T) {
+		migrations, err := readSource(NewFSSource(os.DirFS("./test/migrations5"), ""))
+		assert.Nil(t, err)
+		assert.Len(t, migrations, 2)
+
+		assert.Equal(t, int64(1), migrations[0].Id)
+		assert.Equal(t, "0001_users.up.sql", migrations[0].FileName)
+		assert.True(t, strings.HasPrefix(migrations[0].Up, "CREATE TABLE paired_1"))
+		assert.Equal(t, "DROP TABLE paired_1;", migrations[0].Down)
+		assert.Equal(t, hashRaw(getRaw(migrations[0].Up, migrations[0].Down, DEFAULT_UP_DELIMITER, DEFAULT_DOWN_DELIMITER)), migrations[0].hash)
+
+		assert.Equal(t, "DROP TABLE paired_2;", migrations[1].Down)
+	})
+
+	t.Run("orphaned and mixed files are rejected", func(t *testing.T) {
+		file := &fstest.MapFile{Data: []byte("-- up\nSELECT 1;\n-- down\nSELECT 1;")}
+		cases := map[string]struct {
+			fsys     fstest.MapFS
+			expected string
+		}{
+			"orphaned up": {
+				fstest.MapFS{"1_a.up.sql": file},
+				"up file 1_a.up.sql has no matching down file",
+			},
+			"orphaned down": {
+				fstest.MapFS{"1_a.down.sql": file},
+				"down file 1_a.down.sql has no matching up file",
+			},
+			"mixed layouts": {
+				fstest.MapFS{"1_a.sql": file, "1_a.up.sql": file, "1_a.down.sql": file},
+				"migration 1 mixes a single file and up/down files",
+			},
+			"different names": {
+				fstest.MapFS{"1_a.up.sql": file, "1_b.down.sql": file},
+				"duplicate migration id 1 in 1_a.up.sql and 1_b.down.sql",
+			},
+		}
+
+		for name, c := range cases {
+			_, err := readSource(NewFSSource(c.fsys, ""))
+			if assert.NotNil(t, err, name) {
+				assert.Contains(t, err.Error(), c.expected, name)
+			}
+		}
+	})
+
+	t.Run("migrating up and down files works", func(t *testing.T) {
+		testDbPath := "./test/test_updown1.db"
+		db, err := sql.Open("sqlite3", testDbPath)
+		assert.Nil(t, err)
+		defer db.Close()
+
+		m, err := New(Config{
+			Db: db,
+			Fs: os.DirFS("./test/migrations5"),
+		})
+		assert.Nil(t, err)
+
+		err = m.Migrate()
+		assert.Nil(t, err)
+		tableMustExistSqlite(t, db, "paired_1")
+		tableMustExistSqlite(t, db, "paired_2")
+
+		m.config.Migrations[0].Up = "CREATE TABLE paired_3 (id INTEGER PRIMARY KEY);"
+		m.config.Migrations[0].Down = "DROP TABLE paired_3;"
+		err = m.Migrate()
+		assert.Nil(t, err)
+		tableMustNotExistSqlite(t, db, "paired_1")
+		tableMustExistSqlite(t, db, "paired_3")
+
+		os.Remove(testDbPath)
+	})
+}
+
+func TestFSSourceFolders(t *testing.T) {
+	t.Run("folders are read as one migration", func(t *testing.T) {
+		migrations, err := readSource(NewFSSource(os.DirFS("./test/migrations6"), ""))
+		assert.Nil(t, err)
+		assert.Len(t, migrations, 3)
+
+		orders := migrations[1]
+		assert.Equal(t, int64(2), orders.Id)
+		assert.Equal(t, "0002_orders", orders.FileName)
+		assert.Equal(t, "Orders with a backfill from users", orders.Description)
+		assert.Equal(t, `-- file: 0002_orders/up/01_schema.sql
+CREATE TABLE folder_orders (
+    id INTEGER PRIMARY KEY,
+    user_id INTEGER REFERENCES folder_users(id)
+);
+
+-- file: 0002_orders/up/02_backfill.sql
+INSERT INTO folder_orders (user_id) SELECT id FROM folder_users;`, orders.Up)
+		assert.Equal(t, "DROP TABLE folder_orders;", orders.Down)
+
+		summary := migrations[2]
+		assert.Equal(t, "0003_summary", summary.FileName)
+		assert.Equal(t, "", summary.Down)
+	})
+
+	t.Run("recursive sources read folders too", func(t *testing.T) {
+		source := NewFSSource(os.DirFS("./test"), "migrations6")
+		source.Recursive = true
+		source.Ignore = []string{"notes"}
+
+		migrations, err := readSource(source)
+		assert.Nil(t, err)
+		assert.Len(t, migrations, 3)
+		assert.Equal(t, "0002_orders", migrations[1].FileName)
+	})
+
+	t.Run("invalid folders are rejected", func(t *testing.T) {
+		file := &fstest.MapFile{Data: []byte("SELECT 1;")}
+		cases := map[string]struct {
+			fsys     fstest.MapFS
+			expected string
+		}{
+			"file and directory": {
+				fstest.MapFS{"1_a/up.sql": file, "1_a/up/1.sql": file},
+				"migration folder 1_a has both 1_a/up.sql and 1_a/up",
+			},
+			"empty part": {
+				fstest.MapFS{"1_a/up/notes.txt": file},
+				"migration folder 1_a has no files in 1_a/up",
+			},
+			"unknown meta": {
+				fstest.MapFS{"1_a/up.sql": file, "1_a/meta.yaml": &fstest.MapFile{Data: []byte("owner: me\n")}},
+				"field owner not found",
+			},
+			"duplicate id": {
+				fstest.MapFS{"1_a/up.sql": file, "1_b.sql": &fstest.MapFile{Data: []byte("-- up\n-- down")}},
+				"duplicate migration id 1",
+			},
+		}
+
+		for name, c := range cases {
+			_, err := readSource(NewFSSource(c.fsys, ""))
+			if assert.NotNil(t, err, name) {
+				assert.Contains(t, err.Error(), c.expected, name)
+			}
+		}
+	})
+
+	t.Run("migrating folders works", func(t *testing.T) {
+		testDbPath := "./test/test_folder1.db"
+		db, err := sql.Open("sqlite3", testDbPath)
+		assert.Nil(t, err)
+		defer db.Close()
+
+		m, err := New(Config{
+			Db: db,
+			Fs: os.DirFS("./test/migrations6"),
+		})
+		assert.Nil(t, err)
+
+		err = m.Migrate()
+		assert.Nil(t, err)
+		tableMustExistSqlite(t, db, "folder_users")
+		tableMustExistSqlite(t, db, "folder_orders")
+
+		var up string
+		err = db.QueryRow("SELECT up FROM migrations WHERE id = 2").Scan(&up)
+		assert.Nil(t, err)
+		assert.Contains(t, up, "-- file: 0002_orders/up/02_backfill.sql")
+
+		os.Remove(testDbPath)
+	})
+}
@@ -348,3 +348,44 @@ func tableMustNotExistSqlite(t *testing.T, db *sql.DB, tableName string) {
 	defer rows.Close()
 	assert.False(t, rows.Next(), "table %s must not exist", tableName)
 }
+
+func TestNewValidatesIds(t *testing.T) {
+	testDbPath := "./test/test_validate1.db"
+	db, err := sql.Open("sqlite3", testDbPath)
+	assert.Nil(t, err)
+	defer db.Close()
+
+	_, err = New(Config{
+		Db: db,
+		Migrations: []Migration{
+			{Id: 7, FileName: "0007_a.sql", Up: "SELECT 1;"},
+			{Id: 7, FileName: "7_b.sql", Up: "SELECT 1;"},
+		},
+	})
+	assert.NotNil(t, err)
+	assert.Contains(t, err.Error(), "0007_a.sql and 7_b.sql")
+
+	_, err = New(Config{
+		Db:         db,
+		Migrations: []Migration{{Id: 1}, {Id: 3}},
+		RejectGaps: true,
+	})
+	assert.NotNil(t, err)
+
+	_, err = New(Config{
+		Db:           db,
+		Migrations:   []Migration{{Id: 20261017093000}},
+		RejectGaps:   true,
+		TimestampIDs: true,
+	})
+	assert.NotNil(t, err)
+
+	_, err = New(Config{
+		Db:         db,
+		Migrations: []Migration{{Id: 1}, {Id: 2}},
+		RejectGaps: true,
+	})
+	assert.Nil(t, err)
+
+	os.Remove(testDbPath)
+}
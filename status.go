@@ -0,0 +1,183 @@
+package mig
+
+import (
+	"fmt"
+	"sort"
+)
+
+// MigrationStatus describes the state of a single migration, comparing the
+// migrations table with the source
+type MigrationStatus struct {
+	Id          int64
+	FileName    string
+	Description string
+	Metadata    Metadata
+
+	// Go is true for migrations with Go functions instead of SQL
+	Go bool
+
+	// Repeatable is true for repeatable migrations, which have no id
+	Repeatable bool
+
+	// Applied is true if the migration is recorded in the migrations table
+	Applied bool
+
+	// AppliedOrder is the position in which an applied migration was applied
+	AppliedOrder int64
+
+	// Execution records when, how fast and by whom an applied migration was applied
+	Execution Execution
+
+	// OutOfOrder is true if the migration is not applied but comes before
+	// the last applied one, by id unless dependencies order them otherwise.
+	// Migrate applies it with AllowOutOfOrder and skips it otherwise.
+	OutOfOrder bool
+
+	// Changed is true if the applied migration no longer matches the source,
+	// Migrate will roll back to it and apply it again
+	Changed bool
+
+	// Missing is true if the migration is applied but no longer in the source,
+	// Migrate will roll it back unless it is repeatable
+	Missing bool
+
+	// Diff is a unified diff from the stored migration to the source, set if Changed
+	Diff string
+}
+
+// Status reports every migration that is either in the source or applied,
+// ordered by id, followed by the repeatable migrations ordered by file name
+func (mig *Mig) Status() ([]MigrationStatus, error) {
+	mig.assignRawAndHashes()
+
+	result, err := mig.statusAgainst(mig.config.Migrations)
+	if err != nil {
+		return nil, err
+	}
+
+	repeatables, err := mig.repeatableStatus(mig.repeatables)
+	if err != nil {
+		return nil, err
+	}
+
+	return append(result, repeatables...), nil
+}
+
+// repeatableStatus compares the applied repeatable migrations with the given
+// ones, which must have their raw and hash assigned. A changed repeatable is
+// applied again by Migrate, a missing one is kept.
+func (mig *Mig) repeatableStatus(repeatables []Migration) ([]MigrationStatus, error) {
+	applied, err := mig.getRepeatablesFromDB()
+	if err != nil {
+		return nil, fmt.Errorf("mig: error getting repeatable migrations from db: %w", err)
+	}
+
+	var result []MigrationStatus
+	for _, m := range repeatables {
+		s := MigrationStatus{
+			FileName:    m.FileName,
+			Description: m.Description,
+			Repeatable:  true,
+		}
+
+		if dbMig, ok := applied[m.FileName]; ok {
+			s.Applied = true
+			if dbMig.hash != m.hash {
+				s.Changed = true
+				s.Diff = unifiedDiff("db/"+dbMig.FileName, "source/"+m.FileName, dbMig.raw, m.raw)
+			}
+			delete(applied, m.FileName)
+		}
+
+		result = append(result, s)
+	}
+
+	for _, dbMig := range applied {
+		result = append(result, MigrationStatus{
+			FileName:    dbMig.FileName,
+			Description: dbMig.Description,
+			Repeatable:  true,
+			Applied:     true,
+			Missing:     true,
+		})
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].FileName < result[j].FileName
+	})
+	return result, nil
+}
+
+// statusAgainst compares the migrations table with the given migrations,
+// which must have their raw and hash assigned
+func (mig *Mig) statusAgainst(migrations []Migration) ([]MigrationStatus, error) {
+	dbMigrations, err := mig.getMigrationsFromDB()
+	if err != nil {
+		return nil, fmt.Errorf("mig: error getting migrations from db: %w", err)
+	}
+
+	applied := make(map[int64]Migration, len(dbMigrations))
+	for _, m := range dbMigrations {
+		applied[m.Id] = m
+	}
+	order := mig.sourceOrder()
+
+	var result []MigrationStatus
+	seen := make(map[int64]bool, len(migrations))
+	for _, m := range migrations {
+		seen[m.Id] = true
+		s := MigrationStatus{
+			Id:          m.Id,
+			FileName:    m.FileName,
+			Description: m.Description,
+			Metadata:    m.Metadata,
+			Go:          m.isGo(),
+		}
+
+		if dbMig, ok := applied[m.Id]; ok {
+			s.Applied = true
+			s.AppliedOrder = dbMig.appliedOrder
+			s.Execution = dbMig.execution
+			if dbMig.hash != m.hash {
+				s.Changed = true
+				s.Diff = migrationDiff(dbMig, m)
+			}
+		} else if order.appliedAfter(m.Id, dbMigrations) {
+			s.OutOfOrder = true
+		}
+
+		result = append(result, s)
+	}
+
+	for _, dbMig := range dbMigrations {
+		if seen[dbMig.Id] {
+			continue
+		}
+		result = append(result, MigrationStatus{
+			Id:           dbMig.Id,
+			FileName:     dbMig.FileName,
+			Description:  dbMig.Description,
+			Metadata:     dbMig.Metadata,
+			Go:           isGoRaw(dbMig.raw),
+			Applied:      true,
+			AppliedOrder: dbMig.appliedOrder,
+			Execution:    dbMig.execution,
+			Missing:      true,
+		})
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Id < result[j].Id
+	})
+	return result, nil
+}
+
+// migrationDiff returns a unified diff from the stored copy of a migration to the source
+func migrationDiff(dbMig, m Migration) string {
+	return unifiedDiff(
+		fmt.Sprintf("db/%d", dbMig.Id),
+		fmt.Sprintf("source/%s", m.FileName),
+		dbMig.raw,
+		m.raw,
+	)
+}
@@ -0,0 +1,101 @@
+package mig
+
+import (
+	"database/sql"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStatus(t *testing.T) {
+	t.Run("status reports applied, changed, missing and pending migrations", func(t *testing.T) {
+		testDbPath := "./test/test_status1.db"
+		db, err := sql.Open("sqlite3", testDbPath)
+		assert.Nil(t, err)
+		defer db.Close()
+
+		m, err := New(Config{
+			Db: db,
+			Migrations: []Migration{
+				{
+					Id:   1,
+					Up:   "CREATE TABLE test1 (id INTEGER PRIMARY KEY, name TEXT);",
+					Down: "DROP TABLE test1;",
+				},
+				{
+					Id:   2,
+					Up:   "CREATE TABLE test2 (id INTEGER PRIMARY KEY, name TEXT);",
+					Down: "DROP TABLE test2;",
+				},
+				{
+					Id:   3,
+					Up:   "CREATE TABLE test3 (id INTEGER PRIMARY KEY, name TEXT);",
+					Down: "DROP TABLE test3;",
+				},
+			},
+		})
+		assert.Nil(t, err)
+
+		err = m.Migrate()
+		assert.Nil(t, err)
+
+		m.config.Migrations[1].Up = "CREATE TABLE test2 (id INTEGER PRIMARY KEY, name TEXT, age INTEGER);"
+		m.config.Migrations[2] = Migration{
+			Id:   4,
+			Up:   "CREATE TABLE test4 (id INTEGER PRIMARY KEY, name TEXT);",
+			Down: "DROP TABLE test4;",
+		}
+
+		status, err := m.Status()
+		assert.Nil(t, err)
+		assert.Len(t, status, 4)
+
+		// when and by whom they were applied is covered by TestExecution
+		for i := range status {
+			status[i].Execution = Execution{}
+		}
+		assert.Equal(t, MigrationStatus{Id: 1, Applied: true, AppliedOrder: 1}, status[0])
+
+		assert.Equal(t, int64(2), status[1].Id)
+		assert.True(t, status[1].Applied)
+		assert.True(t, status[1].Changed)
+		assert.Contains(t, status[1].Diff, "-CREATE TABLE test2 (id INTEGER PRIMARY KEY, name TEXT);\n")
+		assert.Contains(t, status[1].Diff, "+CREATE TABLE test2 (id INTEGER PRIMARY KEY, name TEXT, age INTEGER);\n")
+
+		assert.Equal(t, MigrationStatus{Id: 3, Applied: true, AppliedOrder: 3, Missing: true}, status[2])
+		assert.Equal(t, MigrationStatus{Id: 4}, status[3])
+
+		os.Remove(testDbPath)
+	})
+
+	t.Run("failed rollback of a changed migration shows the diff", func(t *testing.T) {
+		testDbPath := "./test/test_status2.db"
+		db, err := sql.Open("sqlite3", testDbPath)
+		assert.Nil(t, err)
+		defer db.Close()
+
+		m, err := New(Config{
+			Db: db,
+			Migrations: []Migration{
+				{
+					Id:   1,
+					Up:   "CREATE TABLE test1 (id INTEGER PRIMARY KEY, name TEXT);",
+					Down: "DROP TABEL test1;",
+				},
+			},
+		})
+		assert.Nil(t, err)
+
+		err = m.Migrate()
+		assert.Nil(t, err)
+
+		m.config.Migrations[0].Down = "DROP TABLE test1;"
+
+		err = m.Migrate()
+		assert.NotNil(t, err)
+		assert.Contains(t, err.Error(), "-DROP TABEL test1;\n+DROP TABLE test1;\n")
+
+		os.Remove(testDbPath)
+	})
+}
@@ -0,0 +1,80 @@
+package mig
+
+import (
+	"database/sql"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTableName(t *testing.T) {
+	t.Run("the tracking table can be renamed", func(t *testing.T) {
+		testDbPath := "./test/test_table1.db"
+		db, err := sql.Open("sqlite3", testDbPath)
+		assert.Nil(t, err)
+		defer db.Close()
+
+		// an application table called migrations does not collide with mig
+		m, err := New(Config{
+			Db:        db,
+			TableName: `schema "versions"`,
+			Migrations: []Migration{
+				{Id: 1, Up: "CREATE TABLE migrations (id INTEGER PRIMARY KEY, name TEXT);", Down: "DROP TABLE migrations;"},
+				{FileName: "R__names.sql", Up: "CREATE VIEW IF NOT EXISTS names AS SELECT name FROM migrations;", Repeatable: true},
+			},
+		})
+		assert.Nil(t, err)
+
+		err = m.Migrate()
+		assert.Nil(t, err)
+		tableMustExistSqlite(t, db, `schema "versions"`)
+		tableMustExistSqlite(t, db, `schema "versions"_repeatable`)
+		tableMustExistSqlite(t, db, `schema "versions"_repairs`)
+		tableMustExistSqlite(t, db, `schema "versions"_history`)
+		assert.Equal(t, 0, countRows(t, db, "migrations"))
+
+		status, err := m.Status()
+		assert.Nil(t, err)
+		assert.True(t, status[0].Applied)
+		assert.True(t, status[1].Applied)
+
+		m.config.Migrations = nil
+		err = m.Migrate()
+		assert.Nil(t, err)
+		tableMustNotExistSqlite(t, db, "migrations")
+
+		err = os.Remove(testDbPath)
+		assert.Nil(t, err)
+	})
+
+	t.Run("a schema is only supported on postgres and sql server", func(t *testing.T) {
+		testDbPath := "./test/test_table2.db"
+		db, err := sql.Open("sqlite3", testDbPath)
+		assert.Nil(t, err)
+		defer db.Close()
+
+		_, err = New(Config{Db: db, Schema: "tracking"})
+		assert.EqualError(t, err, "mig: Schema is not supported on sqlite")
+
+		os.Remove(testDbPath)
+	})
+
+	t.Run("table names are quoted per dialect", func(t *testing.T) {
+		tests := []struct {
+			dialect  dialect
+			schema   string
+			expected string
+		}{
+			{dialectSqlite, "", `"migrations"`},
+			{dialectPostgres, "tracking", `"tracking"."migrations"`},
+			{dialectMssql, "dbo", "[dbo].[migrations]"},
+			{dialectMysql, "", "`migrations`"},
+		}
+
+		for _, tt := range tests {
+			m := &Mig{dialect: tt.dialect, config: Config{Schema: tt.schema}}
+			assert.Equal(t, tt.expected, m.migrationTable())
+		}
+	})
+}
@@ -0,0 +1,20 @@
+package gomigrations
+
+import (
+	"context"
+	"database/sql"
+)
+
+func init() {
+	Registry.Register(upSeedUsers, downSeedUsers)
+}
+
+func upSeedUsers(ctx context.Context, tx *sql.Tx) error {
+	_, err := tx.ExecContext(ctx, "INSERT INTO test_table_1 (name) VALUES ('alice'), ('bob')")
+	return err
+}
+
+func downSeedUsers(ctx context.Context, tx *sql.Tx) error {
+	_, err := tx.ExecContext(ctx, "DELETE FROM test_table_1")
+	return err
+}
@@ -0,0 +1,15 @@
+package gomigrations
+
+import (
+	"context"
+	"database/sql"
+)
+
+func init() {
+	Registry.RegisterVersion("2", upRenameUsers, nil)
+}
+
+func upRenameUsers(ctx context.Context, tx *sql.Tx) error {
+	_, err := tx.ExecContext(ctx, "UPDATE test_table_1 SET name = upper(name)")
+	return err
+}
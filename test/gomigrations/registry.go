@@ -0,0 +1,6 @@
+// Package gomigrations registers Go migrations for the registry tests
+package gomigrations
+
+import "github.com/cprosche/mig"
+
+var Registry = mig.NewRegistry()
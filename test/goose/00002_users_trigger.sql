@@ -0,0 +1,10 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TRIGGER users_name AFTER INSERT ON users
+BEGIN
+    UPDATE users SET name = upper(NEW.name) WHERE id = NEW.id;
+END;
+-- +goose StatementEnd
+
+-- +goose Down
+DROP TRIGGER users_name;
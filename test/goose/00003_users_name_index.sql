@@ -0,0 +1,7 @@
+-- +goose NO TRANSACTION
+
+-- +goose Up
+CREATE INDEX users_name_idx ON users (name);
+
+-- +goose Down
+DROP INDEX users_name_idx;
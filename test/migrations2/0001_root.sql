@@ -0,0 +1,7 @@
+-- up
+CREATE TABLE recursive_1 (
+    id SERIAL PRIMARY KEY,
+    name VARCHAR(255) NOT NULL
+);
+-- down
+DROP TABLE recursive_1;
\ No newline at end of file
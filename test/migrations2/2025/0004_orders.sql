@@ -0,0 +1,7 @@
+-- up
+CREATE TABLE recursive_4 (
+    id SERIAL PRIMARY KEY,
+    name VARCHAR(255) NOT NULL
+);
+-- down
+DROP TABLE recursive_4;
\ No newline at end of file
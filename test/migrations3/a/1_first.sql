@@ -0,0 +1,7 @@
+-- up
+CREATE TABLE duplicate_1 (
+    id SERIAL PRIMARY KEY,
+    name VARCHAR(255) NOT NULL
+);
+-- down
+DROP TABLE duplicate_1;
\ No newline at end of file
@@ -0,0 +1,7 @@
+-- up
+CREATE TABLE duplicate_2 (
+    id SERIAL PRIMARY KEY,
+    name VARCHAR(255) NOT NULL
+);
+-- down
+DROP TABLE duplicate_2;
\ No newline at end of file
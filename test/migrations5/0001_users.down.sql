@@ -0,0 +1 @@
+DROP TABLE paired_1;
@@ -0,0 +1,4 @@
+CREATE TABLE paired_1 (
+    id SERIAL PRIMARY KEY,
+    name VARCHAR(255) NOT NULL
+);
@@ -0,0 +1,7 @@
+-- up
+CREATE TABLE paired_2 (
+    id SERIAL PRIMARY KEY,
+    name VARCHAR(255) NOT NULL
+);
+-- down
+DROP TABLE paired_2;
\ No newline at end of file
@@ -0,0 +1,7 @@
+-- up
+CREATE TABLE folder_users (
+    id INTEGER PRIMARY KEY,
+    name VARCHAR(255) NOT NULL
+);
+-- down
+DROP TABLE folder_users;
\ No newline at end of file
@@ -0,0 +1,4 @@
+CREATE TABLE folder_orders (
+    id INTEGER PRIMARY KEY,
+    user_id INTEGER REFERENCES folder_users(id)
+);
@@ -0,0 +1 @@
+INSERT INTO folder_orders (user_id) SELECT id FROM folder_users;
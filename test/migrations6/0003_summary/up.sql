@@ -0,0 +1 @@
+CREATE VIEW folder_summary AS SELECT COUNT(*) AS orders FROM folder_orders;
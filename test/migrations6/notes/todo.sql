@@ -0,0 +1 @@
+Not a migration.
@@ -0,0 +1,5 @@
+-- up
+CREATE TABLE orders (id INTEGER PRIMARY KEY, total INTEGER);
+
+-- down
+DROP TABLE orders;
@@ -0,0 +1 @@
+CREATE VIEW IF NOT EXISTS order_summary AS SELECT COUNT(*) AS orders FROM orders;
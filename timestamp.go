@@ -0,0 +1,48 @@
+package mig
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// TIMESTAMP_ID_LAYOUT is the layout of timestamp ids, e.g. 20261017093000
+const TIMESTAMP_ID_LAYOUT = "20060102150405"
+
+// timestampIDMaxSkew is how far in the future a timestamp id may be, to allow
+// for clock skew and ids generated from local time instead of UTC
+const timestampIDMaxSkew = 24 * time.Hour
+
+// NewTimestampID returns the timestamp id for t in UTC, e.g. 20261017093000
+func NewTimestampID(t time.Time) int64 {
+	id, _ := strconv.ParseInt(t.UTC().Format(TIMESTAMP_ID_LAYOUT), 10, 64)
+	return id
+}
+
+// GenerateTimestampID returns the timestamp id for the current time, to name
+// a new migration file
+func GenerateTimestampID() int64 {
+	return NewTimestampID(time.Now())
+}
+
+// ParseTimestampID returns the UTC time of a timestamp id. It fails if the id
+// is not a valid date and time after 1970, or more than a day in the future.
+func ParseTimestampID(id int64) (time.Time, error) {
+	s := strconv.FormatInt(id, 10)
+	if len(s) != len(TIMESTAMP_ID_LAYOUT) {
+		return time.Time{}, fmt.Errorf("mig: id %d is not a timestamp like %s", id, TIMESTAMP_ID_LAYOUT)
+	}
+
+	t, err := time.Parse(TIMESTAMP_ID_LAYOUT, s)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("mig: id %d is not a valid timestamp: %w", id, err)
+	}
+	if t.Year() < 1970 {
+		return time.Time{}, fmt.Errorf("mig: id %d is a timestamp before 1970", id)
+	}
+	if t.After(time.Now().Add(timestampIDMaxSkew)) {
+		return time.Time{}, fmt.Errorf("mig: id %d is a timestamp in the future", id)
+	}
+
+	return t, nil
+}
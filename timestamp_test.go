@@ -0,0 +1,88 @@
+package mig
+
+import (
+	"database/sql"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTimestampIDs(t *testing.T) {
+	t.Run("ids are generated from utc time", func(t *testing.T) {
+		local := time.Date(2026, 10, 17, 11, 30, 0, 0, time.FixedZone("CEST", 2*60*60))
+		assert.Equal(t, int64(20261017093000), NewTimestampID(local))
+
+		id := GenerateTimestampID()
+		_, err := ParseTimestampID(id)
+		assert.Nil(t, err)
+	})
+
+	t.Run("implausible timestamps are rejected", func(t *testing.T) {
+		got, err := ParseTimestampID(20261017093000)
+		assert.Nil(t, err)
+		assert.Equal(t, time.Date(2026, 10, 17, 9, 30, 0, 0, time.UTC), got)
+
+		invalid := []int64{
+			1,
+			20261317093000,
+			20261017253000,
+			19691231235959,
+			NewTimestampID(time.Now().Add(48 * time.Hour)),
+			202610170930001,
+		}
+		for _, id := range invalid {
+			_, err := ParseTimestampID(id)
+			assert.NotNil(t, err, "%d", id)
+		}
+	})
+
+	t.Run("timestamp ids larger than 32 bits are migrated", func(t *testing.T) {
+		testDbPath := "./test/test_timestamp1.db"
+		db, err := sql.Open("sqlite3", testDbPath)
+		assert.Nil(t, err)
+		defer db.Close()
+
+		migrations := []Migration{
+			{
+				Id:       20261017093000,
+				FileName: "20261017093000_users.sql",
+				Up:       "CREATE TABLE test1 (id INTEGER PRIMARY KEY, name TEXT);",
+				Down:     "DROP TABLE test1;",
+			},
+			{
+				Id:       20261018120000,
+				FileName: "20261018120000_orders.sql",
+				Up:       "CREATE TABLE test2 (id INTEGER PRIMARY KEY, name TEXT);",
+				Down:     "DROP TABLE test2;",
+			},
+		}
+
+		m, err := New(Config{
+			Db:           db,
+			Migrations:   migrations,
+			TimestampIDs: true,
+		})
+		assert.Nil(t, err)
+
+		err = m.Migrate()
+		assert.Nil(t, err)
+		tableMustExistSqlite(t, db, "test2")
+
+		status, err := m.Status()
+		assert.Nil(t, err)
+		assert.Equal(t, int64(20261018120000), status[1].Id)
+		assert.True(t, status[1].Applied)
+
+		_, err = New(Config{
+			Db:           db,
+			Migrations:   []Migration{{Id: 1, FileName: "0001_users.sql"}},
+			TimestampIDs: true,
+		})
+		assert.NotNil(t, err)
+		assert.Contains(t, err.Error(), "0001_users.sql")
+
+		os.Remove(testDbPath)
+	})
+}
@@ -3,34 +3,21 @@ package mig
 import (
 	"fmt"
 	"hash/fnv"
+	"os"
+	"os/user"
 	"strconv"
 	"strings"
-	"unicode"
 )
 
-func findDelimiterIndex(raw, delimiter string) (int, error) {
-	l := len(delimiter)
-	for i := range raw {
-		if i+l > len(raw) {
-			break
-		}
-
-		if raw[i:i+l] == delimiter {
-			return i, nil
-		}
-	}
-
-	return 0, fmt.Errorf("mig: delimiter not found")
-}
-
 // Expected filename format: 0001_create_users_table.sql.
 // This filename would return 1.
 // Starting number can be any length.
-func getIntFromFileName(fileName string) (int, error) {
+func getIntFromFileName(fileName string) (int64, error) {
 	numStr := ""
 
 	for _, r := range fileName {
-		if !unicode.IsDigit(r) {
+		// only ASCII digits, other unicode digits are not understood by strconv
+		if r < '0' || r > '9' {
 			break
 		}
 
@@ -41,9 +28,9 @@ func getIntFromFileName(fileName string) (int, error) {
 		return 0, fmt.Errorf("mig: no number found in filename")
 	}
 
-	result, err := strconv.Atoi(numStr)
+	result, err := strconv.ParseInt(numStr, 10, 64)
 	if err != nil {
-		return 0, fmt.Errorf("mig: error converting number in filename to int: %w", err)
+		return 0, fmt.Errorf("mig: error converting number in filename to int64: %w", err)
 	}
 	if result < 1 {
 		return 0, fmt.Errorf("mig: number in filename must be greater than 0")
@@ -52,30 +39,21 @@ func getIntFromFileName(fileName string) (int, error) {
 	return result, nil
 }
 
-func splitRaw(raw, upDelimiter, downDelimiter string) (up string, down string, err error) {
-	upStartIndex, err := findDelimiterIndex(raw, upDelimiter)
-	if err != nil {
-		return "", "", err
-	}
+func getRaw(up, down, upDelimiter, downDelimiter string) string {
+	return upDelimiter + "\n" + up + "\n" + downDelimiter + "\n" + down
+}
 
-	downStartIndex, err := findDelimiterIndex(raw, downDelimiter)
-	if err != nil {
-		return "", "", err
+// getSqlRaw returns the text stored and hashed for a SQL migration, which
+// starts with the directives for the options set on it
+func getSqlRaw(m Migration, upDelimiter, downDelimiter string) string {
+	raw := getRaw(m.Up, m.Down, upDelimiter, downDelimiter)
+	if m.SplitStatements {
+		raw = DIRECTIVE_PREFIX + SPLIT_STATEMENTS_DIRECTIVE + "\n" + raw
 	}
-
-	if upStartIndex < downStartIndex {
-		up = strings.TrimSpace(raw[len(upDelimiter):downStartIndex])
-		down = strings.TrimSpace(raw[downStartIndex+len(downDelimiter):])
-	} else {
-		up = strings.TrimSpace(raw[upStartIndex+len(upDelimiter):])
-		down = strings.TrimSpace(raw[len(downDelimiter):upStartIndex])
+	if m.NoTransaction {
+		raw = DIRECTIVE_PREFIX + NO_TRANSACTION_DIRECTIVE + "\n" + raw
 	}
-
-	return up, down, nil
-}
-
-func getRaw(up, down, upDelimiter, downDelimiter string) string {
-	return upDelimiter + "\n" + up + "\n" + downDelimiter + "\n" + down
+	return raw
 }
 
 func hashRaw(s string) string {
@@ -91,3 +69,101 @@ func hashRaw(s string) string {
 
 	return result
 }
+
+// sortByDependencies orders names so that each name comes after the names it
+// depends on, keeping the input order where there is a choice. Dependencies
+// that are not in names are ignored. If there is a cycle, the names involved
+// are appended in input order and an error listing them is returned.
+func sortByDependencies(names []string, deps map[string][]string) ([]string, error) {
+	known := make(map[string]bool, len(names))
+	for _, name := range names {
+		known[name] = true
+	}
+
+	result := make([]string, 0, len(names))
+	done := make(map[string]bool, len(names))
+	for len(result) < len(names) {
+		progress := false
+		for _, name := range names {
+			if done[name] {
+				continue
+			}
+
+			ready := true
+			for _, dep := range deps[name] {
+				if dep != name && known[dep] && !done[dep] {
+					ready = false
+					break
+				}
+			}
+			if !ready {
+				continue
+			}
+
+			result = append(result, name)
+			done[name] = true
+			progress = true
+			break
+		}
+
+		if !progress {
+			var cycle []string
+			for _, name := range names {
+				if !done[name] {
+					cycle = append(cycle, name)
+				}
+			}
+			return append(result, cycle...), fmt.Errorf("mig: dependency cycle between %s", strings.Join(cycle, ", "))
+		}
+	}
+
+	return result, nil
+}
+
+// currentActor describes who is running mig as user@host, for audit records
+func currentActor() string {
+	name := "unknown"
+	if u, err := user.Current(); err == nil {
+		name = u.Username
+	}
+
+	host, err := os.Hostname()
+	if err != nil {
+		host = "unknown"
+	}
+
+	return name + "@" + host
+}
+
+// defaultString returns s, or def if s is empty
+func defaultString(s, def string) string {
+	if s == "" {
+		return def
+	}
+	return s
+}
+
+// validateSequence checks that migrations, which must be sorted by id, have
+// unique ids and, if rejectGaps is set, contiguous ids
+func validateSequence(migrations []Migration, rejectGaps bool) error {
+	for i := 1; i < len(migrations); i++ {
+		prev, m := migrations[i-1], migrations[i]
+
+		if prev.Id == m.Id {
+			return fmt.Errorf("mig: duplicate migration id %d in %s and %s", m.Id, migrationName(prev), migrationName(m))
+		}
+		if rejectGaps && m.Id != prev.Id+1 {
+			return fmt.Errorf("mig: gap in migration ids between %d (%s) and %d (%s)", prev.Id, migrationName(prev), m.Id, migrationName(m))
+		}
+	}
+
+	return nil
+}
+
+// migrationName names a migration in errors, by file name if it has one
+func migrationName(m Migration) string {
+	if m.FileName != "" {
+		return m.FileName
+	}
+	return fmt.Sprintf("migration %d", m.Id)
+}